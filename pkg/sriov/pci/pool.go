@@ -1,4 +1,4 @@
-// Copyright (c) 2020 Doc.ai and/or its affiliates.
+// Copyright (c) 2020-2021 Doc.ai and/or its affiliates.
 //
 // SPDX-License-Identifier: Apache-2.0
 //
@@ -33,11 +33,21 @@ import (
 )
 
 const (
-	vfioDriver        = "vfio-pci"
 	driverBindTimeout = time.Second
 	driverBindCheck   = driverBindTimeout / 10
 )
 
+const (
+	// DefaultPCIDevicesPath is the Linux sysfs PCI devices path
+	DefaultPCIDevicesPath = "/sys/bus/pci/devices"
+	// DefaultPCIDriversPath is the Linux sysfs PCI drivers path
+	DefaultPCIDriversPath = "/sys/bus/pci/drivers"
+	// DefaultIOMMUGroupsPath is the Linux sysfs IOMMU groups path
+	DefaultIOMMUGroupsPath = "/sys/kernel/iommu_groups"
+	// DefaultVFIODir is the Linux vfio devices directory
+	DefaultVFIODir = "/dev/vfio"
+)
+
 type pciFunction interface {
 	GetBoundDriver() (string, error)
 	BindDriver(driver string) error
@@ -50,6 +60,7 @@ type Pool struct {
 	functions             map[string]*function // pciAddr -> *function
 	functionsByIOMMUGroup map[uint][]*function // iommuGroup -> []*function
 	vfioDir               string
+	vfioDriver            string
 	test                  bool
 }
 
@@ -58,16 +69,40 @@ type function struct {
 	kernelDriver string
 }
 
+// NewDefaultPool returns a new PCI Pool using DefaultPCIDevicesPath, DefaultPCIDriversPath and DefaultVFIODir, use
+// NewPool for the custom sysfs mounts
+func NewDefaultPool(cfg *config.Config, options ...Option) (*Pool, error) {
+	return NewPool(DefaultPCIDevicesPath, DefaultPCIDriversPath, DefaultVFIODir, cfg, options...)
+}
+
+// NewStrictPool returns a new PCI Pool like NewPool, but it fails fast if PCI devices or drivers path is not an
+// existing directory
+func NewStrictPool(
+	pciDevicesPath, pciDriversPath, vfioDir string,
+	cfg *config.Config,
+	options ...Option,
+) (*Pool, error) {
+	if err := validateDirs(pciDevicesPath, pciDriversPath); err != nil {
+		return nil, err
+	}
+	return NewPool(pciDevicesPath, pciDriversPath, vfioDir, cfg, options...)
+}
+
 // NewPool returns a new PCI Pool
-func NewPool(pciDevicesPath, pciDriversPath, vfioDir string, cfg *config.Config) (*Pool, error) {
+func NewPool(pciDevicesPath, pciDriversPath, vfioDir string, cfg *config.Config, options ...Option) (*Pool, error) {
 	p := &Pool{
 		functions:             map[string]*function{},
 		functionsByIOMMUGroup: map[uint][]*function{},
 		vfioDir:               vfioDir,
+		vfioDriver:            string(sriov.VFIOPCIDriver),
+	}
+	for _, option := range options {
+		option(p)
 	}
 
 	for pfPCIAddr, pfCfg := range cfg.PhysicalFunctions {
-		pf, err := pcifunction.NewPhysicalFunction(pfPCIAddr, pciDevicesPath, pciDriversPath)
+		pf, err := pcifunction.NewPhysicalFunction(pfPCIAddr, pciDevicesPath, pciDriversPath,
+			pcifunction.WithVFIODriver(p.vfioDriver))
 		if err != nil {
 			return nil, err
 		}
@@ -91,6 +126,7 @@ func NewTestPool(physicalFunctions map[string]*sriovtest.PCIPhysicalFunction, cf
 	p := &Pool{
 		functions:             map[string]*function{},
 		functionsByIOMMUGroup: map[uint][]*function{},
+		vfioDriver:            string(sriov.VFIOPCIDriver),
 		test:                  true,
 	}
 
@@ -110,6 +146,19 @@ func NewTestPool(physicalFunctions map[string]*sriovtest.PCIPhysicalFunction, cf
 	return p, nil
 }
 
+func validateDirs(paths ...string) error {
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return errors.Wrapf(err, "invalid path: %v", path)
+		}
+		if !info.IsDir() {
+			return errors.Errorf("path is not a directory: %v", path)
+		}
+	}
+	return nil
+}
+
 func (p *Pool) addFunction(pcif pciFunction, kernelDriver string) (err error) {
 	f := &function{
 		function:     pcif,
@@ -145,7 +194,7 @@ func (p *Pool) BindDriver(ctx context.Context, iommuGroup uint, driverType sriov
 				return err
 			}
 		case sriov.VFIOPCIDriver:
-			if err := f.function.BindDriver(vfioDriver); err != nil {
+			if err := f.function.BindDriver(p.vfioDriver); err != nil {
 				return err
 			}
 		default:
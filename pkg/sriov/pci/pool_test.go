@@ -0,0 +1,111 @@
+// Copyright (c) 2021 Doc.ai and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pci_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov"
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov/config"
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov/pci"
+)
+
+const (
+	pfPCIAddr = "0000:01:00.0"
+	mkdirPerm = 0750
+	filePerm  = 0600
+)
+
+func TestNewStrictPool(t *testing.T) {
+	tmpDir := filepath.Join(os.TempDir(), t.Name())
+	require.NoError(t, os.RemoveAll(tmpDir))
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+
+	devicesPath := filepath.Join(tmpDir, "devices")
+	driversPath := filepath.Join(tmpDir, "drivers")
+	cfg := &config.Config{}
+
+	_, err := pci.NewStrictPool(devicesPath, driversPath, "", cfg)
+	require.Error(t, err)
+
+	require.NoError(t, os.MkdirAll(devicesPath, mkdirPerm))
+	require.NoError(t, ioutil.WriteFile(driversPath, nil, filePerm))
+
+	_, err = pci.NewStrictPool(devicesPath, driversPath, "", cfg)
+	require.Error(t, err)
+
+	require.NoError(t, os.Remove(driversPath))
+	require.NoError(t, os.MkdirAll(driversPath, mkdirPerm))
+
+	_, err = pci.NewStrictPool(devicesPath, driversPath, "", cfg)
+	require.NoError(t, err)
+}
+
+func TestNewPool_NotExistingPaths(t *testing.T) {
+	tmpDir := filepath.Join(os.TempDir(), t.Name())
+	require.NoError(t, os.RemoveAll(tmpDir))
+
+	_, err := pci.NewPool(filepath.Join(tmpDir, "devices"), filepath.Join(tmpDir, "drivers"), "", &config.Config{})
+	require.NoError(t, err)
+
+	require.NoError(t, pci.UpdateConfig(filepath.Join(tmpDir, "devices"), filepath.Join(tmpDir, "drivers"),
+		&config.Config{}))
+}
+
+func TestPool_BindDriver_WithVFIODriver(t *testing.T) {
+	tmpDir := filepath.Join(os.TempDir(), t.Name())
+	require.NoError(t, os.RemoveAll(tmpDir))
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+
+	devicesPath := filepath.Join(tmpDir, "devices")
+	driversPath := filepath.Join(tmpDir, "drivers")
+	vfioDir := filepath.Join(tmpDir, "vfio")
+
+	devicePath := filepath.Join(devicesPath, pfPCIAddr)
+	require.NoError(t, os.MkdirAll(devicePath, mkdirPerm))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(devicePath, "sriov_totalvfs"), []byte("1"), filePerm))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(devicePath, "sriov_numvfs"), []byte("0"), filePerm))
+
+	iommuGroupPath := filepath.Join(tmpDir, "iommu_groups", "1")
+	require.NoError(t, os.MkdirAll(iommuGroupPath, mkdirPerm))
+	require.NoError(t, os.Symlink(iommuGroupPath, filepath.Join(devicePath, "iommu_group")))
+
+	// device is already bound to the custom vfio driver, so no bind is needed
+	driverPath := filepath.Join(driversPath, "custom-vfio")
+	require.NoError(t, os.MkdirAll(driverPath, mkdirPerm))
+	require.NoError(t, os.Symlink(driverPath, filepath.Join(devicePath, "driver")))
+
+	require.NoError(t, os.MkdirAll(vfioDir, mkdirPerm))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(vfioDir, "1"), nil, filePerm))
+
+	cfg := &config.Config{
+		PhysicalFunctions: map[string]*config.PhysicalFunction{
+			pfPCIAddr: {PFKernelDriver: "ixgbe"},
+		},
+	}
+
+	p, err := pci.NewPool(devicesPath, driversPath, vfioDir, cfg, pci.WithVFIODriver("custom-vfio"))
+	require.NoError(t, err)
+
+	require.NoError(t, p.BindDriver(context.Background(), 1, sriov.VFIOPCIDriver))
+}
@@ -0,0 +1,98 @@
+// Copyright (c) 2021 Doc.ai and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pcifunction
+
+import (
+	"encoding/binary"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	configFile = "config"
+
+	// see PCI_EXT_CAP_* and PCI_ACS_* in linux/pci_regs.h
+	extCapsStart       = 0x100
+	extCapsEnd         = 0x1000
+	extCapHeaderSize   = 4
+	extCapACSID        = 0x000d
+	acsCapRegOffset    = 4
+	acsCtrlRegOffset   = 6
+	acsSourceValid     = 0x0001
+	acsRequestRedir    = 0x0004
+	acsCompletionRedir = 0x0008
+	acsUpstreamFwd     = 0x0010
+)
+
+// IsACSEnabled returns true if f has Access Control Services isolation enabled: all of Source Validation, P2P
+// Request Redirect, P2P Completion Redirect, Upstream Forwarding supported by f are enabled. Returns ErrUnsupported
+// if f has no ACS capability. Extended config space is readable only with CAP_SYS_ADMIN.
+func (f *Function) IsACSEnabled() (bool, error) {
+	configPath := f.withDevicePath(configFile)
+	config, err := ioutil.ReadFile(filepath.Clean(configPath))
+	switch {
+	case os.IsNotExist(err):
+		return false, errors.Wrapf(ErrAttributeNotFound, "file doesn't exist: %v", configPath)
+	case err != nil:
+		return false, errors.Wrapf(err, "unable to read file: %v", configPath)
+	case len(config) < extCapsStart+extCapHeaderSize:
+		return false, errors.Errorf("extended config space is not available for the device: %v", f.address)
+	}
+
+	capOffset, err := findExtCap(config, extCapACSID)
+	if err != nil {
+		return false, errors.Wrapf(err, "invalid config space for the device: %v", f.address)
+	}
+	if capOffset == 0 || capOffset+acsCtrlRegOffset+2 > len(config) {
+		return false, errors.Wrapf(ErrUnsupported, "ACS is not supported for the device: %v", f.address)
+	}
+
+	acsCap := binary.LittleEndian.Uint16(config[capOffset+acsCapRegOffset:])
+	acsCtrl := binary.LittleEndian.Uint16(config[capOffset+acsCtrlRegOffset:])
+
+	required := acsCap & (acsSourceValid | acsRequestRedir | acsCompletionRedir | acsUpstreamFwd)
+	return acsCtrl&required == required, nil
+}
+
+// findExtCap returns offset of the PCIe extended capability with the given ID or 0 if there is no such capability.
+// Extended capability header is "<ID:16> <version:4> <next offset:12>".
+func findExtCap(config []byte, id uint16) (int, error) {
+	visited := map[int]bool{}
+	for offset := extCapsStart; offset != 0; {
+		if offset < extCapsStart || offset+extCapHeaderSize > len(config) || offset >= extCapsEnd {
+			return 0, errors.Errorf("extended capability offset is out of range: %#x", offset)
+		}
+		if visited[offset] {
+			return 0, errors.Errorf("extended capabilities loop at: %#x", offset)
+		}
+		visited[offset] = true
+
+		header := binary.LittleEndian.Uint32(config[offset:])
+		switch {
+		case header == 0 || header == 0xffffffff:
+			// no extended capabilities
+			return 0, nil
+		case uint16(header) == id:
+			return offset, nil
+		}
+		offset = int(header>>20) &^ 0x3
+	}
+	return 0, nil
+}
@@ -0,0 +1,80 @@
+// Copyright (c) 2021 Doc.ai and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//+build !windows
+
+package pcifunction_test
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov/pcifunction"
+)
+
+const (
+	aerCapOffset = 0x100
+	acsCapOffset = 0x148
+)
+
+func newConfig(withACS bool, acsCtrl uint16) string {
+	config := make([]byte, 0x1000)
+
+	// AER capability
+	next := uint32(0)
+	if withACS {
+		next = acsCapOffset
+	}
+	binary.LittleEndian.PutUint32(config[aerCapOffset:], 0x0001|1<<16|next<<20)
+
+	if withACS {
+		binary.LittleEndian.PutUint32(config[acsCapOffset:], 0x000d|1<<16)
+		binary.LittleEndian.PutUint16(config[acsCapOffset+4:], 0x001d)
+		binary.LittleEndian.PutUint16(config[acsCapOffset+6:], acsCtrl)
+	}
+
+	return string(config)
+}
+
+func TestFunction_IsACSEnabled(t *testing.T) {
+	s := newSysfs(t)
+	s.createPF(t, pfPCIAddr, 1)
+	pf := s.newPF(t, pfPCIAddr)
+
+	_, err := pf.IsACSEnabled()
+	require.True(t, errors.Is(err, pcifunction.ErrAttributeNotFound))
+
+	s.writeFile(t, pfPCIAddr, "config", string(make([]byte, 64)))
+	_, err = pf.IsACSEnabled()
+	require.Error(t, err)
+
+	s.writeFile(t, pfPCIAddr, "config", newConfig(false, 0))
+	_, err = pf.IsACSEnabled()
+	require.True(t, errors.Is(err, pcifunction.ErrUnsupported))
+
+	s.writeFile(t, pfPCIAddr, "config", newConfig(true, 0x0001))
+	enabled, err := pf.IsACSEnabled()
+	require.NoError(t, err)
+	require.False(t, enabled)
+
+	s.writeFile(t, pfPCIAddr, "config", newConfig(true, 0x001d))
+	enabled, err = pf.IsACSEnabled()
+	require.NoError(t, err)
+	require.True(t, enabled)
+}
@@ -0,0 +1,94 @@
+// Copyright (c) 2021 Doc.ai and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pcifunction
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	resourceFile = "resource"
+	barsCount    = 6
+
+	// see IORESOURCE_* in linux/ioport.h
+	ioResourceIO    = 0x00000100
+	ioResourceMem64 = 0x00100000
+)
+
+// BARInfo describes PCI device Base Address Register
+type BARInfo struct {
+	Index int
+	Start uint64
+	End   uint64
+	Flags uint64
+}
+
+// Size returns BAR size in bytes
+func (b *BARInfo) Size() uint64 {
+	return b.End - b.Start + 1
+}
+
+// IsIO returns true if BAR is an I/O port BAR
+func (b *BARInfo) IsIO() bool {
+	return b.Flags&ioResourceIO != 0
+}
+
+// Is64Bit returns true if BAR is a 64-bit memory BAR, such BAR also takes the next BAR index which is not reported
+func (b *BARInfo) Is64Bit() bool {
+	return b.Flags&ioResourceMem64 != 0
+}
+
+// GetDeviceResources returns f BARs, unused BARs and upper halves of 64-bit BARs are skipped. Returns
+// ErrAttributeNotFound if f has no resource file.
+func (f *Function) GetDeviceResources() ([]*BARInfo, error) {
+	resource, err := f.readAttribute(resourceFile)
+	if err != nil {
+		return nil, err
+	}
+
+	bars, err := parseResource(resource)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid resource file for the device: %v", f.address)
+	}
+	return bars, nil
+}
+
+// parseResource parses sysfs PCI device resource file: each line has "<start> <end> <flags>" hex values, the first
+// barsCount lines are the device BARs
+func parseResource(resource string) ([]*BARInfo, error) {
+	var bars []*BARInfo
+	for i, line := range strings.Split(resource, "\n") {
+		if i == barsCount {
+			break
+		}
+
+		bar := &BARInfo{Index: i}
+		if _, err := fmt.Sscanf(line, "0x%x 0x%x 0x%x", &bar.Start, &bar.End, &bar.Flags); err != nil {
+			return nil, errors.Wrapf(err, "invalid resource line: %v", line)
+		}
+
+		if bar.Start == 0 && bar.End == 0 {
+			// unused BAR or the upper half of the previous 64-bit BAR
+			continue
+		}
+		bars = append(bars, bar)
+	}
+	return bars, nil
+}
@@ -0,0 +1,72 @@
+// Copyright (c) 2021 Doc.ai and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//+build !windows
+
+package pcifunction_test
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov/pcifunction"
+)
+
+// resource file of Intel 82599ES 10-Gigabit PF
+const resource = `0x00000000fb200000 0x00000000fb2fffff 0x000000000014220c
+0x0000000000000000 0x0000000000000000 0x0000000000000000
+0x000000000000e020 0x000000000000e03f 0x0000000000040101
+0x0000000000000000 0x0000000000000000 0x0000000000000000
+0x00000000fb404000 0x00000000fb407fff 0x000000000014220c
+0x0000000000000000 0x0000000000000000 0x0000000000000000
+0x00000000fb080000 0x00000000fb0fffff 0x0000000000046200
+0x0000000000000000 0x0000000000000000 0x0000000000000000
+0x0000000000000000 0x0000000000000000 0x0000000000000000
+0x0000000000000000 0x0000000000000000 0x0000000000000000
+0x0000000000000000 0x0000000000000000 0x0000000000000000
+0x0000000000000000 0x0000000000000000 0x0000000000000000
+0x0000000000000000 0x0000000000000000 0x0000000000000000
+`
+
+func TestFunction_GetDeviceResources(t *testing.T) {
+	s := newSysfs(t)
+	s.createPF(t, pfPCIAddr, 1)
+	pf := s.newPF(t, pfPCIAddr)
+
+	_, err := pf.GetDeviceResources()
+	require.True(t, errors.Is(err, pcifunction.ErrAttributeNotFound))
+
+	s.writeFile(t, pfPCIAddr, "resource", resource)
+
+	bars, err := pf.GetDeviceResources()
+	require.NoError(t, err)
+	require.Len(t, bars, 3)
+
+	require.Equal(t, 0, bars[0].Index)
+	require.Equal(t, uint64(0x100000), bars[0].Size())
+	require.True(t, bars[0].Is64Bit())
+	require.False(t, bars[0].IsIO())
+
+	require.Equal(t, 2, bars[1].Index)
+	require.Equal(t, uint64(0x20), bars[1].Size())
+	require.True(t, bars[1].IsIO())
+
+	require.Equal(t, 4, bars[2].Index)
+	require.Equal(t, uint64(0x4000), bars[2].Size())
+	require.True(t, bars[2].Is64Bit())
+}
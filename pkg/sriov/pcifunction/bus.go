@@ -0,0 +1,50 @@
+// Copyright (c) 2021 Doc.ai and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pcifunction
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	rescanFile = "rescan"
+)
+
+// RescanPCIBus triggers PCI bus rescan, pciBusPath is a path to the PCI bus directory (e.g. /sys/bus/pci). Returns
+// ErrUnsupported if there is no writable rescan file in pciBusPath.
+func RescanPCIBus(pciBusPath string) error {
+	rescanPath := filepath.Join(pciBusPath, rescanFile)
+	switch info, err := os.Stat(rescanPath); {
+	case os.IsNotExist(err):
+		return errors.Wrapf(ErrUnsupported, "PCI bus rescan is not supported: %v", rescanPath)
+	case err != nil:
+		return errors.Wrapf(err, "failed to get PCI bus rescan file info: %v", rescanPath)
+	case info.Mode().Perm()&0200 == 0:
+		return errors.Wrapf(ErrUnsupported, "PCI bus rescan file is read only: %v", rescanPath)
+	}
+
+	switch err := writeFile(rescanPath, "1"); {
+	case os.IsPermission(errors.Cause(err)):
+		return errors.Wrapf(err, "insufficient privileges to rescan PCI bus: %v", rescanPath)
+	case err != nil:
+		return errors.Wrapf(err, "failed to rescan PCI bus: %v", rescanPath)
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+// Copyright (c) 2021 Doc.ai and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//+build !windows
+
+package pcifunction_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov/pcifunction"
+)
+
+func TestRescanPCIBus(t *testing.T) {
+	tmpDir := filepath.Join(os.TempDir(), t.Name())
+	require.NoError(t, os.MkdirAll(tmpDir, mkdirPerm))
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+
+	rescanPath := filepath.Join(tmpDir, "rescan")
+	require.NoError(t, ioutil.WriteFile(rescanPath, nil, filePerm))
+
+	require.NoError(t, pcifunction.RescanPCIBus(tmpDir))
+
+	data, err := ioutil.ReadFile(filepath.Clean(rescanPath))
+	require.NoError(t, err)
+	require.Equal(t, "1", string(data))
+}
+
+func TestRescanPCIBus_Unsupported(t *testing.T) {
+	tmpDir := filepath.Join(os.TempDir(), t.Name())
+	require.NoError(t, os.MkdirAll(tmpDir, mkdirPerm))
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+
+	rescanPath := filepath.Join(tmpDir, "rescan")
+
+	require.True(t, errors.Is(pcifunction.RescanPCIBus(tmpDir), pcifunction.ErrUnsupported))
+	_, err := os.Stat(rescanPath)
+	require.True(t, os.IsNotExist(err))
+
+	require.NoError(t, ioutil.WriteFile(rescanPath, nil, 0400))
+
+	require.True(t, errors.Is(pcifunction.RescanPCIBus(tmpDir), pcifunction.ErrUnsupported))
+
+	data, err := ioutil.ReadFile(filepath.Clean(rescanPath))
+	require.NoError(t, err)
+	require.Empty(t, data)
+}
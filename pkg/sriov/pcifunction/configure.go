@@ -0,0 +1,51 @@
+// Copyright (c) 2021 Doc.ai and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//+build !windows
+
+package pcifunction
+
+import (
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"golang.org/x/sys/unix"
+)
+
+// CanConfigure checks that the current process has write access to the pf sysfs files used to configure the pf: VFs
+// number, bound driver unbind and vfio driver bind files. It doesn't write anything, so it is safe to call before the
+// first configuration.
+func (pf *PhysicalFunction) CanConfigure() error {
+	paths := []string{pf.withDevicePath(configuredVFFile)}
+
+	switch driver, err := pf.GetBoundDriver(); {
+	case err != nil:
+		return err
+	case driver != "":
+		paths = append(paths, pf.withDevicePath(boundDriverPath, unbindDriverPath))
+	}
+
+	if vfioBindPath := filepath.Join(pf.pciDriversPath, pf.vfioDriver, bindDriverPath); isFileExists(vfioBindPath) {
+		paths = append(paths, vfioBindPath)
+	}
+
+	for _, path := range paths {
+		if err := unix.Access(path, unix.W_OK); err != nil {
+			return errors.Wrapf(err, "no write access to %v for the device: %v", path, pf.address)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+// Copyright (c) 2021 Doc.ai and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pcifunction
+
+// DeviceInfo describes PCI device
+type DeviceInfo struct {
+	PCIAddress string
+	// Driver is bound driver name, "" if no driver bound
+	Driver string
+	// Class is PCI class code in the sysfs format (e.g. "0x020000")
+	Class string
+	// Revision is PCI revision ID in the sysfs format (e.g. "0x01")
+	Revision string
+	IsBridge bool
+}
+
+// GetDeviceInfo returns f device info
+func (f *Function) GetDeviceInfo() (*DeviceInfo, error) {
+	driver, err := f.GetBoundDriver()
+	if err != nil {
+		return nil, err
+	}
+
+	class, err := f.GetDeviceClass()
+	if err != nil {
+		return nil, err
+	}
+
+	isBridge, err := f.IsBridge()
+	if err != nil {
+		return nil, err
+	}
+
+	revision, err := f.GetRevisionID()
+	if err != nil {
+		return nil, err
+	}
+
+	return &DeviceInfo{
+		PCIAddress: f.address,
+		Driver:     driver,
+		Class:      class,
+		Revision:   revision,
+		IsBridge:   isBridge,
+	}, nil
+}
+
+// GetIOMMUGroupDeviceInfos returns info of all devices in the f IOMMU group including f itself sorted by PCI address.
+// Devices removed during the call are skipped.
+func (f *Function) GetIOMMUGroupDeviceInfos() ([]*DeviceInfo, error) {
+	pciAddrs, err := f.GetIOMMUGroupDevices()
+	if err != nil {
+		return nil, err
+	}
+
+	var infos []*DeviceInfo
+	for _, pciAddr := range pciAddrs {
+		device := f.newFunction(pciAddr)
+
+		info, err := device.GetDeviceInfo()
+		switch {
+		case err != nil && device.isRemoved():
+			continue
+		case err != nil:
+			return nil, err
+		}
+		infos = append(infos, info)
+	}
+
+	return infos, nil
+}
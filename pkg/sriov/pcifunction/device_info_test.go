@@ -0,0 +1,69 @@
+// Copyright (c) 2021 Doc.ai and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//+build !windows
+
+package pcifunction_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov/pcifunction"
+)
+
+func TestFunction_GetIOMMUGroupDeviceInfos(t *testing.T) {
+	s := newSysfs(t)
+
+	s.createPF(t, pfPCIAddr, 1)
+	s.writeFile(t, pfPCIAddr, "class", "0x020000\n")
+	s.writeFile(t, pfPCIAddr, "revision", "0x01\n")
+	s.addToIOMMUGroup(t, pfPCIAddr, 1)
+	s.bindDriver(t, pfPCIAddr, "ixgbe")
+
+	s.createDevice(t, "0000:00:01.0")
+	s.writeFile(t, "0000:00:01.0", "class", "0x060400\n")
+	s.writeFile(t, "0000:00:01.0", "revision", "0x02\n")
+	s.addToIOMMUGroup(t, "0000:00:01.0", 1)
+	s.bindDriver(t, "0000:00:01.0", "pcieport")
+
+	s.createDevice(t, "0000:01:00.1")
+	s.addToIOMMUGroup(t, "0000:01:00.1", 1)
+	require.NoError(t, os.RemoveAll(filepath.Join(s.devicesPath, "0000:01:00.1")))
+
+	pf := s.newPF(t, pfPCIAddr)
+
+	infos, err := pf.GetIOMMUGroupDeviceInfos()
+	require.NoError(t, err)
+	require.Equal(t, []*pcifunction.DeviceInfo{
+		{
+			PCIAddress: "0000:00:01.0",
+			Driver:     "pcieport",
+			Class:      "0x060400",
+			Revision:   "0x02",
+			IsBridge:   true,
+		},
+		{
+			PCIAddress: pfPCIAddr,
+			Driver:     "ixgbe",
+			Class:      "0x020000",
+			Revision:   "0x01",
+		},
+	}, infos)
+}
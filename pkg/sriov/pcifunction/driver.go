@@ -0,0 +1,47 @@
+// Copyright (c) 2021 Doc.ai and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pcifunction
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+// GetDevicesBoundToDriver returns sorted PCI addresses of the devices bound to the driver, returns ErrDriverNotLoaded if
+// there is no such driver in pciDriversPath
+func GetDevicesBoundToDriver(pciDriversPath, driver string) ([]string, error) {
+	fInfos, err := ioutil.ReadDir(filepath.Join(pciDriversPath, driver))
+	switch {
+	case os.IsNotExist(err):
+		return nil, errors.Wrapf(ErrDriverNotLoaded, "driver: %v", driver)
+	case err != nil:
+		return nil, errors.Wrapf(err, "failed to read driver directory: %v", driver)
+	}
+
+	var pciAddrs []string
+	for _, fInfo := range fInfos {
+		// driver directory also has bind, unbind, module and other files
+		if validLongPCIAddr.MatchString(fInfo.Name()) {
+			pciAddrs = append(pciAddrs, fInfo.Name())
+		}
+	}
+
+	return pciAddrs, nil
+}
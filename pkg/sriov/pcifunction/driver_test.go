@@ -0,0 +1,49 @@
+// Copyright (c) 2021 Doc.ai and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//+build !windows
+
+package pcifunction_test
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov/pcifunction"
+)
+
+func TestGetDevicesBoundToDriver(t *testing.T) {
+	s := newSysfs(t)
+
+	_, err := pcifunction.GetDevicesBoundToDriver(s.driversPath, "vfio-pci")
+	require.True(t, errors.Is(err, pcifunction.ErrDriverNotLoaded))
+
+	for _, pciAddr := range []string{"0000:01:00.2", "0000:01:00.1", "0000:01:00.3"} {
+		s.createDevice(t, pciAddr)
+	}
+	s.bindDriver(t, "0000:01:00.2", "vfio-pci")
+	s.bindDriver(t, "0000:01:00.1", "vfio-pci")
+	s.bindDriver(t, "0000:01:00.3", "ixgbevf")
+	require.NoError(t, ioutil.WriteFile(filepath.Join(s.driversPath, "vfio-pci", "bind"), nil, filePerm))
+
+	pciAddrs, err := pcifunction.GetDevicesBoundToDriver(s.driversPath, "vfio-pci")
+	require.NoError(t, err)
+	require.Equal(t, []string{"0000:01:00.1", "0000:01:00.2"}, pciAddrs)
+}
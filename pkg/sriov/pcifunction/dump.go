@@ -0,0 +1,75 @@
+// Copyright (c) 2021 Doc.ai and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pcifunction
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+// Dump writes human readable report about pf and its VFs: bound drivers, IOMMU groups, NUMA nodes, net interfaces.
+// Errors getting device info are written inline, only write errors are returned.
+func (pf *PhysicalFunction) Dump(w io.Writer) error {
+	if _, err := fmt.Fprintf(w, "PF %v\n", pf.address); err != nil {
+		return errors.Wrap(err, "failed to write dump")
+	}
+	if err := pf.dump(w, "  "); err != nil {
+		return err
+	}
+
+	for _, vf := range pf.virtualFunctions {
+		if _, err := fmt.Fprintf(w, "  VF %d %v\n", vf.vfIndex, vf.address); err != nil {
+			return errors.Wrap(err, "failed to write dump")
+		}
+		if err := vf.dump(w, "    "); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (f *Function) dump(w io.Writer, indent string) error {
+	driver, err := f.GetBoundDriver()
+	if err := dumpValue(w, indent, "driver", driver, err); err != nil {
+		return err
+	}
+
+	iommuGroup, err := f.GetIOMMUGroup()
+	if err := dumpValue(w, indent, "IOMMU group", iommuGroup, err); err != nil {
+		return err
+	}
+
+	numaNode, err := f.GetNUMANode()
+	if err := dumpValue(w, indent, "NUMA node", numaNode, err); err != nil {
+		return err
+	}
+
+	ifNames, err := f.GetNetInterfacesNames()
+	return dumpValue(w, indent, "net interfaces", ifNames, err)
+}
+
+func dumpValue(w io.Writer, indent, name string, value interface{}, valueErr error) (err error) {
+	if valueErr != nil {
+		_, err = fmt.Fprintf(w, "%s%s: error: %v\n", indent, name, valueErr)
+	} else {
+		_, err = fmt.Fprintf(w, "%s%s: %v\n", indent, name, value)
+	}
+	return errors.Wrap(err, "failed to write dump")
+}
@@ -0,0 +1,52 @@
+// Copyright (c) 2021 Doc.ai and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//+build !windows
+
+package pcifunction_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPhysicalFunction_Dump(t *testing.T) {
+	s := newSysfs(t)
+
+	s.createPF(t, pfPCIAddr, 1)
+	s.createVF(t, pfPCIAddr, 0, "0000:01:00.1")
+	s.writeFile(t, pfPCIAddr, "numa_node", "0\n")
+	s.writeFile(t, "0000:01:00.1", "numa_node", "0\n")
+	s.addToIOMMUGroup(t, "0000:01:00.1", 5)
+	s.bindDriver(t, "0000:01:00.1", "ixgbevf")
+	s.writeFile(t, "0000:01:00.1", "net/eth1/type", "1\n")
+
+	pf := s.newPF(t, pfPCIAddr)
+
+	sb := new(strings.Builder)
+	require.NoError(t, pf.Dump(sb))
+
+	dump := sb.String()
+	require.Contains(t, dump, "PF "+pfPCIAddr+"\n")
+	require.Contains(t, dump, "  IOMMU group: error: ")
+	require.Contains(t, dump, "  VF 0 0000:01:00.1\n"+
+		"    driver: ixgbevf\n"+
+		"    IOMMU group: 5\n"+
+		"    NUMA node: 0\n"+
+		"    net interfaces: [eth1]\n")
+}
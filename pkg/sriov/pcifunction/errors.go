@@ -0,0 +1,113 @@
+// Copyright (c) 2021 Doc.ai and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pcifunction
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	// ErrDeviceNotFound is returned when the PCI device doesn't exist
+	ErrDeviceNotFound = errors.New("device not found")
+	// ErrAttributeNotFound is returned when the device has no requested sysfs attribute
+	ErrAttributeNotFound = errors.New("attribute not found")
+	// ErrUnsupported is returned when the feature is not supported by the kernel or by the device
+	ErrUnsupported = errors.New("unsupported")
+	// ErrInvalidAttribute is returned when the requested sysfs attribute path is invalid
+	ErrInvalidAttribute = errors.New("invalid attribute")
+	// ErrDriverNotLoaded is returned when the driver is not loaded
+	ErrDriverNotLoaded = errors.New("driver not loaded")
+	// ErrNoDriverBound is returned when no driver is bound to the device
+	ErrNoDriverBound = errors.New("no driver bound")
+	// ErrNoParentBridge is returned when the device has no upstream PCI bridge
+	ErrNoParentBridge = errors.New("no parent bridge")
+	// ErrEmptyCPUList is returned when the device CPU list file has no CPUs
+	ErrEmptyCPUList = errors.New("empty CPU list")
+	// ErrInvalidCPUList is returned when the device CPU list file has invalid format
+	ErrInvalidCPUList = errors.New("invalid CPU list")
+	// ErrNoNetInterface is returned when the device has no net interface
+	ErrNoNetInterface = errors.New("no net interface")
+	// ErrInvalidVFsCount is returned when the requested VFs number is less than 1 or exceeds the PF VFs capacity
+	ErrInvalidVFsCount = errors.New("invalid VFs count")
+	// ErrMSIXBudgetExceeded is returned when the requested MSI-X vectors don't fit the PF VFs MSI-X budget
+	ErrMSIXBudgetExceeded = errors.New("MSI-X budget exceeded")
+	// ErrUnknownLinkSpeed is returned when the device PCIe link speed can't be mapped to PCIe generation
+	ErrUnknownLinkSpeed = errors.New("unknown link speed")
+	// ErrPathEscape is returned when the sysfs symlink resolves out of the configured sysfs root
+	ErrPathEscape = errors.New("path escapes sysfs root")
+	// ErrNotVirtualFunction is returned when the device is expected to be a VF, but it isn't
+	ErrNotVirtualFunction = errors.New("not a virtual function")
+	// ErrInconsistentSriovTopology is returned when the VF physfn link points to the PF not having the VF in its
+	// virtfnN links
+	ErrInconsistentSriovTopology = errors.New("inconsistent SR-IOV topology")
+	// ErrInvalidVFLayout is returned when the PF virtfnN links don't match the PF SR-IOV offset and stride
+	ErrInvalidVFLayout = errors.New("invalid VF layout")
+)
+
+// MultiError is an aggregated error of a batch operation, it maps device PCI address (or driver name) to the error of
+// the operation for this device. errors.Is and errors.As match MultiError if they match any of its errors.
+type MultiError map[string]error
+
+// Error returns all errors messages sorted by key
+func (e MultiError) Error() string {
+	var msgs []string
+	for _, key := range e.keys() {
+		msgs = append(msgs, key+": "+e[key].Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Unwrap returns all errors sorted by key
+func (e MultiError) Unwrap() []error {
+	var errs []error
+	for _, key := range e.keys() {
+		errs = append(errs, e[key])
+	}
+	return errs
+}
+
+// Is returns true if any of e errors matches target
+func (e MultiError) Is(target error) bool {
+	for _, err := range e.Unwrap() {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// As finds the first of e errors sorted by key that matches target
+func (e MultiError) As(target interface{}) bool {
+	for _, err := range e.Unwrap() {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+func (e MultiError) keys() []string {
+	var keys []string
+	for key := range e {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
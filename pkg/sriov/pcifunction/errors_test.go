@@ -0,0 +1,52 @@
+// Copyright (c) 2021 Doc.ai and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//+build !windows
+
+package pcifunction_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov/pcifunction"
+)
+
+func TestMultiError(t *testing.T) {
+	pathErr := &os.PathError{Op: "open", Path: "sriov_numvfs", Err: os.ErrPermission}
+	err := errors.Wrap(pcifunction.MultiError{
+		"0000:01:00.2": errors.Wrap(pcifunction.ErrDeviceNotFound, "0000:01:00.2"),
+		"0000:01:00.1": errors.Wrap(pathErr, "0000:01:00.1"),
+	}, "batch failed")
+
+	require.Equal(t, "batch failed: 0000:01:00.1: 0000:01:00.1: open sriov_numvfs: permission denied; "+
+		"0000:01:00.2: 0000:01:00.2: device not found", err.Error())
+
+	require.True(t, errors.Is(err, pcifunction.ErrDeviceNotFound))
+	require.True(t, errors.Is(err, os.ErrPermission))
+	require.False(t, errors.Is(err, pcifunction.ErrUnsupported))
+
+	var target *os.PathError
+	require.True(t, errors.As(err, &target))
+	require.Equal(t, pathErr, target)
+
+	var multiErr pcifunction.MultiError
+	require.True(t, errors.As(err, &multiErr))
+	require.Len(t, multiErr.Unwrap(), 2)
+}
@@ -1,4 +1,4 @@
-// Copyright (c) 2020 Doc.ai and/or its affiliates.
+// Copyright (c) 2020-2021 Doc.ai and/or its affiliates.
 //
 // SPDX-License-Identifier: Apache-2.0
 //
@@ -17,27 +17,73 @@
 package pcifunction
 
 import (
+	"context"
 	"io/ioutil"
+	"os"
 	"path"
 	"path/filepath"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/pkg/errors"
+
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov"
 )
 
 const (
 	netInterfacesPath = "net"
 	iommuGroup        = "iommu_group"
+	iommuGroupDevices = "devices"
+	classFile         = "class"
+	pciBridgeClass    = 0x0604
+	networkClass      = 0x02
 	boundDriverPath   = "driver"
 	bindDriverPath    = "bind"
 	unbindDriverPath  = "unbind"
+	driverOverride    = "driver_override"
+	driversProbe      = "drivers_probe"
+	noDriverOverride  = "(null)"
+	localCPUListFile  = "local_cpulist"
+	numaNodeFile      = "numa_node"
+	modaliasFile      = "modalias"
+	revisionFile      = "revision"
+	netInterfaceType  = "type"
+	netInterfaceIndex = "ifindex"
+	aerCorrectable    = "aer_dev_correctable"
+	aerFatal          = "aer_dev_fatal"
+	aerNonFatal       = "aer_dev_nonfatal"
+	physFnPath        = "physfn"
+	vfMSIXCountFile   = "sriov_vf_msix_count"
+	vfTotalMSIXFile   = "sriov_vf_total_msix"
+	netInterfaceCheck = 100 * time.Millisecond
+	driverBindCheck   = 100 * time.Millisecond
+	driverBindTimeout = time.Second
+)
+
+// NoNUMANode is the NUMA node reported for devices without NUMA affinity
+const NoNUMANode = -1
+
+// InterfaceKind is a net interface hardware type, see ARPHRD_* in linux/if_arp.h
+type InterfaceKind uint
+
+const (
+	// EthernetInterface is Ethernet net interface kind
+	EthernetInterface InterfaceKind = 1
+	// InfinibandInterface is InfiniBand net interface kind
+	InfinibandInterface InterfaceKind = 32
 )
 
 // Function describes Linux PCI function
 type Function struct {
-	address        string
-	pciDevicesPath string
-	pciDriversPath string
+	address            string
+	pciDevicesPath     string
+	pciDriversPath     string
+	vfioDriver         string
+	originalDriver     string
+	dedupNetInterfaces bool
+	// vfIndex is the f virtfnN index, it is set only for the VFs loaded by PhysicalFunction
+	vfIndex int
 }
 
 // GetPCIAddress returns f PCI address
@@ -45,21 +91,122 @@ func (f *Function) GetPCIAddress() string {
 	return f.address
 }
 
-// GetNetInterfaceName returns f net interface name
-func (f *Function) GetNetInterfaceName() (string, error) {
+// GetNetInterfacesNames returns f net interfaces names sorted by name, all entries in the f net directory are
+// returned regardless of the interface kind. If f is created with WithNetInterfacesDedup, only the first name is
+// returned for the entries having the same ifindex.
+func (f *Function) GetNetInterfacesNames() ([]string, error) {
 	fInfos, err := ioutil.ReadDir(f.withDevicePath(netInterfacesPath))
 	if err != nil {
-		return "", errors.Wrapf(err, "failed to read net directory for the device: %v", f.address)
+		return nil, errors.Wrapf(err, "failed to read net directory for the device: %v", f.address)
 	}
 
 	var ifNames []string
+	ifIndexes := map[uint]bool{}
 	for _, fInfo := range fInfos {
-		ifNames = append(ifNames, fInfo.Name())
+		ifName := fInfo.Name()
+		if f.dedupNetInterfaces {
+			ifIndex, err := f.getNetInterfaceIndex(ifName)
+			if err != nil {
+				return nil, err
+			}
+			if ifIndexes[ifIndex] {
+				continue
+			}
+			ifIndexes[ifIndex] = true
+		}
+		ifNames = append(ifNames, ifName)
+	}
+
+	return ifNames, nil
+}
+
+// GetPrimaryNetInterface returns f net interface name having the lowest ifindex, if there are multiple interfaces
+// having the lowest ifindex, returns the first one sorted by name
+func (f *Function) GetPrimaryNetInterface() (string, error) {
+	ifNames, err := f.GetNetInterfacesNames()
+	if err != nil {
+		return "", err
+	}
+
+	var primaryIfName string
+	var primaryIfIndex uint
+	for _, ifName := range ifNames {
+		ifIndex, err := f.getNetInterfaceIndex(ifName)
+		if err != nil {
+			return "", err
+		}
+		if primaryIfName == "" || ifIndex < primaryIfIndex {
+			primaryIfName, primaryIfIndex = ifName, ifIndex
+		}
+	}
+
+	if primaryIfName == "" {
+		return "", errors.Wrapf(ErrNoNetInterface, "no interfaces found for the device: %v", f.address)
+	}
+	return primaryIfName, nil
+}
+
+func (f *Function) getNetInterfaceIndex(ifName string) (uint, error) {
+	ifIndex, err := readUintFromFile(f.withDevicePath(netInterfacesPath, ifName, netInterfaceIndex))
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to get net interface index for the device: %v %v", f.address, ifName)
+	}
+	return ifIndex, nil
+}
+
+// GetNetInterfacesNamesByType returns f net interfaces names of the given kind sorted by name. Unlike
+// GetNetInterfacesNames, it skips interfaces of other kinds.
+func (f *Function) GetNetInterfacesNamesByType(kind InterfaceKind) ([]string, error) {
+	ifNames, err := f.GetNetInterfacesNames()
+	if err != nil {
+		return nil, err
+	}
+
+	var filteredIfNames []string
+	for _, ifName := range ifNames {
+		ifType, err := readUintFromFile(f.withDevicePath(netInterfacesPath, ifName, netInterfaceType))
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get net interface type for the device: %v %v", f.address, ifName)
+		}
+		if InterfaceKind(ifType) == kind {
+			filteredIfNames = append(filteredIfNames, ifName)
+		}
+	}
+
+	return filteredIfNames, nil
+}
+
+// GetNetInterfaceStates returns f net interface name -> operational state map, state is the interface operstate:
+// "up", "down", "unknown", etc. VF interfaces often report "unknown" if VF driver doesn't track the link state, so
+// "unknown" should not be treated as "down".
+func (f *Function) GetNetInterfaceStates() (map[string]string, error) {
+	ifNames, err := f.GetNetInterfacesNames()
+	if err != nil {
+		return nil, err
+	}
+
+	states := map[string]string{}
+	for _, ifName := range ifNames {
+		state, err := f.readAttribute(filepath.Join(netInterfacesPath, ifName, operStateFile))
+		if err != nil {
+			return nil, err
+		}
+		states[ifName] = state
+	}
+
+	return states, nil
+}
+
+// GetNetInterfaceName returns f net interface name
+func (f *Function) GetNetInterfaceName() (string, error) {
+	ifNames, err := f.GetNetInterfacesNames()
+	if err != nil {
+		return "", err
 	}
 
 	switch len(ifNames) {
 	case 0:
-		return "", errors.Errorf("no interfaces found for the device: %v - %+v", f.address, ifNames)
+		return "", errors.Wrapf(ErrNoNetInterface, "no interfaces found for the device: %v - %+v", f.address, ifNames)
 	case 1:
 		return ifNames[0], nil
 	default:
@@ -67,6 +214,82 @@ func (f *Function) GetNetInterfaceName() (string, error) {
 	}
 }
 
+// WaitForNetInterface waits until f gets at least one net interface and returns its name. If there are multiple
+// interfaces, returns the first one sorted by name.
+func (f *Function) WaitForNetInterface(ctx context.Context) (string, error) {
+	for {
+		if ifNames, err := f.GetNetInterfacesNames(); err == nil && len(ifNames) > 0 {
+			return ifNames[0], nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return "", errors.Wrapf(ctx.Err(), "no interfaces found for the device: %v", f.address)
+		case <-time.After(netInterfaceCheck):
+		}
+	}
+}
+
+// GetPhysicalFunctionPCIAddress returns PCI address of the f PF, if f is a VF. Returns ErrInconsistentSriovTopology
+// if the PF has no virtfnN link back to f.
+func (f *Function) GetPhysicalFunctionPCIAddress() (string, error) {
+	pfPCIAddr, err := evalSymlinkAndGetBaseName(f.withDevicePath(physFnPath))
+	if err != nil {
+		return "", errors.Wrapf(err, "error evaluating PF for the device: %v", f.address)
+	}
+
+	if !validLongPCIAddr.MatchString(pfPCIAddr) {
+		return "", errors.Errorf("invalid PF PCI address for the device: %v %v", f.address, pfPCIAddr)
+	}
+
+	vfDirs, err := filepath.Glob(filepath.Join(f.pciDevicesPath, pfPCIAddr, virtualFunctionPrefix+"*"))
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to find virtual function directories for the device: %v", pfPCIAddr)
+	}
+	for _, vfDir := range vfDirs {
+		linkName, err := os.Readlink(vfDir)
+		if err != nil {
+			continue
+		}
+		if vfPCIAddr, err := normalizePCIAddress(filepath.Base(linkName)); err == nil && vfPCIAddr == f.address {
+			return pfPCIAddr, nil
+		}
+	}
+
+	return "", errors.Wrapf(ErrInconsistentSriovTopology, "PF doesn't have the device as a VF: %v %v",
+		pfPCIAddr, f.address)
+}
+
+// AssertIsVirtualFunction returns nil if f is a VF, ErrNotVirtualFunction if f is not a VF and ErrDeviceNotFound if
+// f doesn't exist
+func (f *Function) AssertIsVirtualFunction() error {
+	_, err := os.Lstat(f.withDevicePath(physFnPath))
+	switch {
+	case err == nil:
+		return nil
+	case !os.IsNotExist(err):
+		return errors.Wrapf(err, "failed to check PF for the device: %v", f.address)
+	case f.isRemoved():
+		return errors.Wrapf(ErrDeviceNotFound, "PCI device doesn't exist: %v", f.address)
+	default:
+		return errors.Wrapf(ErrNotVirtualFunction, "PCI device is not a VF: %v", f.address)
+	}
+}
+
+// GetSriovCapacityInfo returns f SR-IOV VFs capacity and true if f is SR-IOV capable. If f is not SR-IOV capable at
+// all, returns (0, false, nil). If f is SR-IOV capable but SR-IOV is disabled (e.g. in the device firmware), returns
+// (0, true, nil).
+func (f *Function) GetSriovCapacityInfo() (capacity int, capable bool, err error) {
+	totalVFs, err := readUintFromFile(f.withDevicePath(totalVFFile))
+	switch {
+	case errors.Is(err, ErrAttributeNotFound):
+		return 0, false, nil
+	case err != nil:
+		return 0, false, errors.Wrapf(err, "failed to get available VFs number for the PCI device: %v", f.address)
+	}
+	return int(totalVFs), true, nil
+}
+
 // GetIOMMUGroup returns f IOMMU group id
 func (f *Function) GetIOMMUGroup() (uint, error) {
 	stringIOMMUGroup, err := evalSymlinkAndGetBaseName(f.withDevicePath(iommuGroup))
@@ -79,20 +302,330 @@ func (f *Function) GetIOMMUGroup() (uint, error) {
 	return uint(iommuGroup), nil
 }
 
-// GetBoundDriver returns driver name that is bound to f, if no driver bound, returns ""
+// GetIOMMUGroupDevices returns PCI addresses of all devices in the f IOMMU group, including f itself
+func (f *Function) GetIOMMUGroupDevices() ([]string, error) {
+	fInfos, err := ioutil.ReadDir(f.withDevicePath(iommuGroup, iommuGroupDevices))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read IOMMU group devices for the device: %v", f.address)
+	}
+
+	var pciAddrs []string
+	for _, fInfo := range fInfos {
+		pciAddrs = append(pciAddrs, fInfo.Name())
+	}
+
+	return pciAddrs, nil
+}
+
+// GetIOMMUGroupEndpoints returns PCI addresses of the devices in the f IOMMU group excluding PCI bridges. Only
+// endpoints can be bound to the vfio-pci driver, so these are the devices to check for passthrough. Devices removed
+// during the call are skipped.
+func (f *Function) GetIOMMUGroupEndpoints() ([]string, error) {
+	pciAddrs, err := f.GetIOMMUGroupDevices()
+	if err != nil {
+		return nil, err
+	}
+
+	var endpoints []string
+	for _, pciAddr := range pciAddrs {
+		device := f.newFunction(pciAddr)
+
+		isBridge, err := device.IsBridge()
+		switch {
+		case err != nil && device.isRemoved():
+			continue
+		case err != nil:
+			return nil, err
+		case isBridge:
+			continue
+		}
+		endpoints = append(endpoints, pciAddr)
+	}
+
+	return endpoints, nil
+}
+
+// IsVFIOGroupViable returns true if all endpoints in the f IOMMU group are either bound to vfio driver or not bound
+// to any driver, so the group can be opened by vfio. If not, also returns PCI addresses of the endpoints bound to the
+// other drivers.
+func (f *Function) IsVFIOGroupViable() (bool, []string, error) {
+	endpoints, err := f.GetIOMMUGroupEndpoints()
+	if err != nil {
+		return false, nil, err
+	}
+
+	var boundEndpoints []string
+	for _, endpoint := range endpoints {
+		switch driver, err := f.newFunction(endpoint).GetBoundDriver(); {
+		case err != nil:
+			return false, nil, err
+		case driver != "" && driver != f.vfioDriver:
+			boundEndpoints = append(boundEndpoints, endpoint)
+		}
+	}
+
+	return len(boundEndpoints) == 0, boundEndpoints, nil
+}
+
+// GetParentBridge returns PCI address of the upstream PCI bridge of f, returns ErrNoParentBridge if f is on the root
+// bus
+func (f *Function) GetParentBridge() (string, error) {
+	devicePath, err := filepath.EvalSymlinks(f.withDevicePath())
+	if err != nil {
+		return "", errors.Wrapf(err, "error evaluating device path for the device: %v", f.address)
+	}
+
+	parent := filepath.Base(filepath.Dir(devicePath))
+	if !validLongPCIAddr.MatchString(parent) {
+		return "", errors.Wrapf(ErrNoParentBridge, "device: %v", f.address)
+	}
+
+	return parent, nil
+}
+
+// GetDeviceClass returns f PCI class code in the sysfs format (e.g. "0x020000")
+func (f *Function) GetDeviceClass() (string, error) {
+	return f.readAttribute(classFile)
+}
+
+// GetModalias returns f modalias, it is used by the kernel to find the driver module for f. Returns
+// ErrAttributeNotFound if f has no modalias.
+func (f *Function) GetModalias() (string, error) {
+	return f.readAttribute(modaliasFile)
+}
+
+// GetRevisionID returns f PCI revision ID in the sysfs format (e.g. "0x01"). Returns ErrAttributeNotFound if f has no
+// revision.
+func (f *Function) GetRevisionID() (string, error) {
+	return f.readAttribute(revisionFile)
+}
+
+// IsNetworkDevice returns true if f is a network controller (PCI base class 0x02)
+func (f *Function) IsNetworkDevice() (bool, error) {
+	classCode, err := f.getClassCode()
+	if err != nil {
+		return false, err
+	}
+	return classCode>>16 == networkClass, nil
+}
+
+// IsBridge returns true if f is a PCI-to-PCI bridge (PCI class 0x0604). Returns ErrAttributeNotFound if f has no
+// class.
+func (f *Function) IsBridge() (bool, error) {
+	classCode, err := f.getClassCode()
+	if err != nil {
+		return false, err
+	}
+	return classCode>>8 == pciBridgeClass, nil
+}
+
+func (f *Function) getClassCode() (uint64, error) {
+	classCode, err := readHexUintFromFile(f.withDevicePath(classFile))
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to get class for the device: %v", f.address)
+	}
+	return classCode, nil
+}
+
+// GetBoundDriver returns driver name that is bound to f, if no driver bound, returns "". Returns ErrPathEscape if
+// the driver link resolves out of the PCI drivers directory.
 func (f *Function) GetBoundDriver() (string, error) {
-	if !isFileExists(f.withDevicePath(boundDriverPath)) {
+	driver, err := evalSymlinkAndGetBaseName(f.withDevicePath(boundDriverPath))
+	switch {
+	case errors.Is(err, os.ErrNotExist):
 		return "", nil
+	case err != nil:
+		return "", errors.Wrapf(err, "error evaluating bound driver for the device: %v", f.address)
 	}
 
-	driver, err := evalSymlinkAndGetBaseName(f.withDevicePath(boundDriverPath))
+	if err := checkSymlinkWithinRoot(f.withDevicePath(boundDriverPath), f.pciDriversPath); err != nil {
+		return "", errors.Wrapf(err, "invalid bound driver for the device: %v", f.address)
+	}
+
+	return driver, nil
+}
+
+// GetDriverType returns type of the driver bound to f: sriov.NoDriver if no driver is bound, sriov.VFIOPCIDriver if
+// vfio driver is bound, else sriov.KernelDriver
+func (f *Function) GetDriverType() (sriov.DriverType, error) {
+	switch driver, err := f.GetBoundDriver(); {
+	case err != nil:
+		return "", err
+	case driver == "":
+		return sriov.NoDriver, nil
+	case driver == f.vfioDriver:
+		return sriov.VFIOPCIDriver, nil
+	default:
+		return sriov.KernelDriver, nil
+	}
+}
+
+// GetLocalCPUs returns CPUs local to f, usually these are CPUs of the f NUMA node
+func (f *Function) GetLocalCPUs() ([]int, error) {
+	data, err := readFile(f.withDevicePath(localCPUListFile))
 	if err != nil {
-		return "", errors.Wrapf(err, "error evaluating bound driver for the device: %v", f.address)
+		return nil, errors.Wrapf(err, "failed to read local CPU list for the device: %v", f.address)
+	}
+
+	cpus, err := parseCPUList(data)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse local CPU list for the device: %v", f.address)
+	}
+
+	return cpus, nil
+}
+
+// GetBoundDrivers returns map of PCI address -> bound driver name for the given functions, "" if no driver bound. If
+// some lookups fail, returns the successful ones together with MultiError of the failed lookups.
+func GetBoundDrivers(functions []*Function) (map[string]string, error) {
+	drivers := map[string]string{}
+	errs := MultiError{}
+	for _, f := range functions {
+		driver, err := f.GetBoundDriver()
+		if err != nil {
+			errs[f.address] = err
+			continue
+		}
+		drivers[f.address] = driver
 	}
 
+	if len(errs) > 0 {
+		return drivers, errs
+	}
+	return drivers, nil
+}
+
+// GetBoundDriverStrict returns driver name that is bound to f. Unlike GetBoundDriver, if no driver bound, returns
+// ErrNoDriverBound error instead of ""
+func (f *Function) GetBoundDriverStrict() (string, error) {
+	driver, err := f.GetBoundDriver()
+	if err != nil {
+		return "", err
+	}
+	if driver == "" {
+		return "", errors.Wrapf(ErrNoDriverBound, "device: %v", f.address)
+	}
 	return driver, nil
 }
 
+// GetNUMANode returns f NUMA node, if f has no NUMA affinity returns NoNUMANode
+func (f *Function) GetNUMANode() (int, error) {
+	stringNUMANode, err := f.readAttribute(numaNodeFile)
+	if err != nil {
+		return 0, err
+	}
+
+	numaNode, err := strconv.Atoi(stringNUMANode)
+	if err != nil {
+		return 0, errors.Wrapf(err, "invalid NUMA node for the device: %v %v", f.address, stringNUMANode)
+	}
+	if numaNode < 0 {
+		return NoNUMANode, nil
+	}
+
+	return numaNode, nil
+}
+
+// GetMSIXCount returns number of MSI-X vectors assigned to the f VF, returns ErrUnsupported if the kernel or the
+// device doesn't support dynamic MSI-X vectors assignment
+func (f *Function) GetMSIXCount() (int, error) {
+	msixCount, err := readUintFromFile(f.withDevicePath(vfMSIXCountFile))
+	switch {
+	case errors.Is(err, ErrAttributeNotFound):
+		return 0, errors.Wrapf(ErrUnsupported, "MSI-X count is not supported for the device: %v", f.address)
+	case err != nil:
+		return 0, errors.Wrapf(err, "failed to get MSI-X count for the device: %v", f.address)
+	}
+	return int(msixCount), nil
+}
+
+// SetMSIXCount assigns msixCount MSI-X vectors to the f VF, returns ErrNotVirtualFunction if f is not a VF and
+// ErrUnsupported if the kernel or the device doesn't support dynamic MSI-X vectors assignment. If PF reports MSI-X
+// vectors budget for its VFs, msixCount is validated against it.
+func (f *Function) SetMSIXCount(msixCount int) error {
+	if msixCount < 0 {
+		return errors.Errorf("invalid MSI-X count for the device: %v %v", f.address, msixCount)
+	}
+	if err := f.AssertIsVirtualFunction(); err != nil {
+		return err
+	}
+
+	switch totalMSIX, err := readUintFromFile(f.withDevicePath(physFnPath, vfTotalMSIXFile)); {
+	case errors.Is(err, ErrAttributeNotFound):
+	case err != nil:
+		return errors.Wrapf(err, "failed to get VFs MSI-X budget for the device: %v", f.address)
+	case uint(msixCount) > totalMSIX:
+		return errors.Wrapf(ErrMSIXBudgetExceeded, "MSI-X count exceeds VFs MSI-X budget for the device: %v %v > %v",
+			f.address, msixCount, totalMSIX)
+	}
+
+	err := f.writeAttribute(vfMSIXCountFile, strconv.Itoa(msixCount))
+	if errors.Is(err, ErrAttributeNotFound) {
+		return errors.Wrapf(ErrUnsupported, "MSI-X count is not supported for the device: %v", f.address)
+	}
+	return err
+}
+
+// SetNUMANode overrides f NUMA node, it is useful for the platforms reporting wrong NUMA node for the devices. Returns
+// ErrUnsupported if the kernel doesn't allow to override NUMA node.
+func (f *Function) SetNUMANode(numaNode int) error {
+	if numaNode < 0 {
+		return errors.Errorf("invalid NUMA node for the device: %v %v", f.address, numaNode)
+	}
+
+	switch info, err := os.Stat(f.withDevicePath(numaNodeFile)); {
+	case os.IsNotExist(err):
+		return errors.Wrapf(ErrUnsupported, "NUMA node is not supported for the device: %v", f.address)
+	case err != nil:
+		return errors.Wrapf(err, "failed to get NUMA node info for the device: %v", f.address)
+	case info.Mode().Perm()&0200 == 0:
+		return errors.Wrapf(ErrUnsupported, "NUMA node is read only for the device: %v", f.address)
+	}
+
+	return f.writeAttribute(numaNodeFile, strconv.Itoa(numaNode))
+}
+
+// GetAERStats returns f total number of correctable, fatal and non-fatal AER errors, returns ErrUnsupported if AER
+// stats are not exposed for f
+func (f *Function) GetAERStats() (correctable, fatal, nonfatal uint64, err error) {
+	if correctable, err = f.readAERTotal(aerCorrectable); err != nil {
+		return 0, 0, 0, err
+	}
+	if fatal, err = f.readAERTotal(aerFatal); err != nil {
+		return 0, 0, 0, err
+	}
+	if nonfatal, err = f.readAERTotal(aerNonFatal); err != nil {
+		return 0, 0, 0, err
+	}
+	return correctable, fatal, nonfatal, nil
+}
+
+func (f *Function) readAERTotal(name string) (uint64, error) {
+	stats, err := f.readAttribute(name)
+	switch {
+	case errors.Is(err, ErrAttributeNotFound):
+		return 0, errors.Wrapf(ErrUnsupported, "AER is not supported for the device: %v", f.address)
+	case err != nil:
+		return 0, err
+	}
+
+	// AER stats file has "<ERROR_NAME> <count>" lines, the total is on the "TOTAL_ERR_*" line
+	for _, line := range strings.Split(stats, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 || !strings.HasPrefix(fields[0], "TOTAL_ERR_") {
+			continue
+		}
+
+		total, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			return 0, errors.Wrapf(err, "invalid %v for the device: %v", name, f.address)
+		}
+		return total, nil
+	}
+
+	return 0, errors.Errorf("no total errors count in %v for the device: %v", name, f.address)
+}
+
 // BindDriver unbinds currently bound driver and binds the given driver to f
 func (f *Function) BindDriver(driver string) error {
 	switch boundDriver, err := f.GetBoundDriver(); {
@@ -101,9 +634,8 @@ func (f *Function) BindDriver(driver string) error {
 	case boundDriver == driver:
 		return nil
 	case boundDriver != "":
-		unbindPath := filepath.Join(f.withDevicePath(boundDriverPath, unbindDriverPath))
-		if err := ioutil.WriteFile(unbindPath, []byte(f.address), 0); err != nil {
-			return errors.Wrapf(err, "failed to unbind driver from the device: %v", f.address)
+		if err := f.unbindDriver(); err != nil {
+			return err
 		}
 	}
 
@@ -118,6 +650,223 @@ func (f *Function) BindDriver(driver string) error {
 	return nil
 }
 
+// BindToVFIO binds vfio driver to f using driver_override. It is idempotent: stale driver_override left by a
+// previous attempt is replaced, bound driver is unbound only if it differs from vfio driver and the final bound
+// driver is verified with WaitForVFIOBound until ctx is done. Unbound driver is remembered by f, use
+// RestoreOriginalDriver to bind it back.
+func (f *Function) BindToVFIO(ctx context.Context) error {
+	switch override, err := f.readAttribute(driverOverride); {
+	case err != nil:
+		return err
+	case override != f.vfioDriver:
+		if override != noDriverOverride && override != "" {
+			if err := f.writeAttribute(driverOverride, "\n"); err != nil {
+				return errors.Wrapf(err, "failed to clear stale driver override for the device: %v", f.address)
+			}
+		}
+		if err := f.writeAttribute(driverOverride, f.vfioDriver); err != nil {
+			return errors.Wrapf(err, "failed to set driver override for the device: %v", f.address)
+		}
+	}
+
+	switch boundDriver, err := f.GetBoundDriver(); {
+	case err != nil:
+		return err
+	case boundDriver == f.vfioDriver:
+		return nil
+	case boundDriver != "":
+		f.originalDriver = boundDriver
+		if err := f.unbindDriver(); err != nil {
+			return err
+		}
+	}
+
+	probePath := filepath.Join(filepath.Dir(f.pciDriversPath), driversProbe)
+	if err := writeFile(probePath, f.address); err != nil {
+		return errors.Wrapf(err, "failed to probe driver for the device: %v", f.address)
+	}
+
+	return f.WaitForVFIOBound(ctx)
+}
+
+// RestoreOriginalDriver clears driver_override and binds back the driver unbound from f by BindToVFIO waiting for it
+// until ctx is done. Returns ErrNoDriverBound if there is no such driver.
+func (f *Function) RestoreOriginalDriver(ctx context.Context) error {
+	if f.originalDriver == "" {
+		return errors.Wrapf(ErrNoDriverBound, "no original driver recorded for the device: %v", f.address)
+	}
+
+	if err := f.writeAttribute(driverOverride, "\n"); err != nil {
+		return errors.Wrapf(err, "failed to clear driver override for the device: %v", f.address)
+	}
+
+	switch boundDriver, err := f.GetBoundDriver(); {
+	case err != nil:
+		return err
+	case boundDriver == f.originalDriver:
+	default:
+		if boundDriver != "" {
+			if err := f.unbindDriver(); err != nil {
+				return err
+			}
+		}
+
+		// Write to the driver/bind file may fail but still bind the driver (see BindDriver), so we check only that the
+		// driver exists and wait for it to be bound
+		bindPath := filepath.Join(f.pciDriversPath, f.originalDriver, bindDriverPath)
+		if err := writeFile(bindPath, f.address); errors.Is(err, ErrAttributeNotFound) {
+			return errors.Wrapf(ErrDriverNotLoaded, "failed to bind the driver to the device: %v %v",
+				f.address, f.originalDriver)
+		}
+		if err := f.waitForDriver(ctx, f.originalDriver); err != nil {
+			return err
+		}
+	}
+	f.originalDriver = ""
+
+	return nil
+}
+
+// WaitForVFIOBound waits until vfio driver gets bound to f, e.g. after driver probe which is asynchronous
+func (f *Function) WaitForVFIOBound(ctx context.Context) error {
+	return f.waitForDriver(ctx, f.vfioDriver)
+}
+
+func (f *Function) waitForDriver(ctx context.Context, driver string) error {
+	for {
+		boundDriver, err := f.GetBoundDriver()
+		if err == nil && boundDriver == driver {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return errors.Wrapf(ctx.Err(), "%v driver is not bound to the device: %v, bound driver: %q",
+				driver, f.address, boundDriver)
+		case <-time.After(driverBindCheck):
+		}
+	}
+}
+
+// PrepareForVFIO makes f ready for passthrough: binds vfio driver to f with BindToVFIO waiting for it until ctx is done
+// and checks that f IOMMU group is viable. On failure driver unbound from f is restored with RestoreOriginalDriver, if
+// f had no driver bound, driver_override is cleared and vfio driver is unbound. All the steps are idempotent, so failed
+// call can be retried.
+func (f *Function) PrepareForVFIO(ctx context.Context) (err error) {
+	initialDriver, err := f.GetBoundDriver()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err == nil {
+			return
+		}
+
+		var restoreErr error
+		switch {
+		case f.originalDriver != "":
+			// ctx may be already done here, so the original driver is restored with its own timeout
+			restoreCtx, cancel := context.WithTimeout(context.Background(), driverBindTimeout)
+			defer cancel()
+
+			restoreErr = f.RestoreOriginalDriver(restoreCtx)
+		case initialDriver == "":
+			restoreErr = f.restoreUnbound()
+		}
+		if restoreErr != nil {
+			err = errors.Wrapf(err, "failed to restore original driver: %v", restoreErr)
+		}
+	}()
+
+	if err = f.BindToVFIO(ctx); err != nil {
+		return err
+	}
+
+	viable, boundEndpoints, err := f.IsVFIOGroupViable()
+	switch {
+	case err != nil:
+		return err
+	case !viable:
+		return errors.Errorf("IOMMU group is not viable for the device: %v, endpoints bound to other drivers: %v",
+			f.address, boundEndpoints)
+	}
+
+	return nil
+}
+
+// restoreUnbound clears driver_override and unbinds driver bound to f by BindToVFIO, if f had no driver bound
+func (f *Function) restoreUnbound() error {
+	if err := f.writeAttribute(driverOverride, "\n"); err != nil {
+		return errors.Wrapf(err, "failed to clear driver override for the device: %v", f.address)
+	}
+
+	switch boundDriver, err := f.GetBoundDriver(); {
+	case err != nil:
+		return err
+	case boundDriver != "":
+		return f.unbindDriver()
+	}
+	return nil
+}
+
+func (f *Function) unbindDriver() error {
+	unbindPath := f.withDevicePath(boundDriverPath, unbindDriverPath)
+	if err := ioutil.WriteFile(unbindPath, []byte(f.address), 0); err != nil {
+		return errors.Wrapf(err, "failed to unbind driver from the device: %v", f.address)
+	}
+	return nil
+}
+
+// ReadAttribute returns value of the f sysfs attribute. It is a low level API: attr can be any path relative to the
+// f sysfs directory (e.g. "power/control"), it is only checked to not escape the directory.
+func (f *Function) ReadAttribute(attr string) (string, error) {
+	if err := validateAttribute(attr); err != nil {
+		return "", err
+	}
+	return f.readAttribute(attr)
+}
+
+// WriteAttribute writes value to the f sysfs attribute. It is a low level API: attr can be any path relative to the
+// f sysfs directory, it is only checked to not escape the directory, value is written as is.
+func (f *Function) WriteAttribute(attr, value string) error {
+	if err := validateAttribute(attr); err != nil {
+		return err
+	}
+	return f.writeAttribute(attr, value)
+}
+
+func (f *Function) newFunction(pciAddr string) *Function {
+	return &Function{
+		address:            pciAddr,
+		pciDevicesPath:     f.pciDevicesPath,
+		pciDriversPath:     f.pciDriversPath,
+		vfioDriver:         f.vfioDriver,
+		dedupNetInterfaces: f.dedupNetInterfaces,
+	}
+}
+
+// isRemoved returns true if f device directory doesn't exist, it is used to skip devices hot unplugged during
+// enumeration while still reporting read errors for the existing devices
+func (f *Function) isRemoved() bool {
+	return !isFileExists(f.withDevicePath())
+}
+
+func (f *Function) readAttribute(name string) (string, error) {
+	value, err := readFile(f.withDevicePath(name))
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to read %v for the device: %v", name, f.address)
+	}
+	return value, nil
+}
+
+func (f *Function) writeAttribute(name, value string) error {
+	if err := writeFile(f.withDevicePath(name), value); err != nil {
+		return errors.Wrapf(err, "failed to write %v for the device: %v", name, f.address)
+	}
+	return nil
+}
+
 func (f *Function) withDevicePath(elem ...string) string {
 	return path.Join(append([]string{f.pciDevicesPath, f.address}, elem...)...)
 }
@@ -0,0 +1,943 @@
+// Copyright (c) 2021 Doc.ai and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//+build !windows
+
+package pcifunction_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov"
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov/pcifunction"
+)
+
+const (
+	mkdirPerm = 0750
+	filePerm  = 0600
+
+	pfPCIAddr = "0000:01:00.0"
+
+	devicesDir     = "devices"
+	driversDir     = "drivers"
+	iommuGroupsDir = "iommu_groups"
+)
+
+type sysfs struct {
+	devicesPath     string
+	driversPath     string
+	iommuGroupsPath string
+}
+
+func newSysfs(t *testing.T) *sysfs {
+	tmpDir := filepath.Join(os.TempDir(), t.Name())
+	require.NoError(t, os.RemoveAll(tmpDir))
+	t.Cleanup(func() { _ = os.RemoveAll(tmpDir) })
+
+	s := &sysfs{
+		devicesPath:     filepath.Join(tmpDir, devicesDir),
+		driversPath:     filepath.Join(tmpDir, driversDir),
+		iommuGroupsPath: filepath.Join(tmpDir, iommuGroupsDir),
+	}
+	require.NoError(t, os.MkdirAll(s.devicesPath, mkdirPerm))
+	require.NoError(t, os.MkdirAll(s.driversPath, mkdirPerm))
+	require.NoError(t, os.MkdirAll(s.iommuGroupsPath, mkdirPerm))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(tmpDir, "drivers_probe"), nil, filePerm))
+
+	return s
+}
+
+func (s *sysfs) createDevice(t *testing.T, pciAddr string) string {
+	devicePath := filepath.Join(s.devicesPath, pciAddr)
+	require.NoError(t, os.MkdirAll(devicePath, mkdirPerm))
+	return devicePath
+}
+
+func (s *sysfs) createPF(t *testing.T, pciAddr string, vfsCount int) string {
+	devicePath := s.createDevice(t, pciAddr)
+	s.writeFile(t, pciAddr, "sriov_totalvfs", "8")
+	s.writeFile(t, pciAddr, "sriov_numvfs", strconv.Itoa(vfsCount))
+	return devicePath
+}
+
+func (s *sysfs) writeFile(t *testing.T, pciAddr, name, data string) {
+	filePath := filepath.Join(s.devicesPath, pciAddr, name)
+	require.NoError(t, os.MkdirAll(filepath.Dir(filePath), mkdirPerm))
+	require.NoError(t, ioutil.WriteFile(filePath, []byte(data), filePerm))
+}
+
+func (s *sysfs) addToIOMMUGroup(t *testing.T, pciAddr string, iommuGroup int) {
+	iommuGroupPath := filepath.Join(s.iommuGroupsPath, strconv.Itoa(iommuGroup))
+	require.NoError(t, os.MkdirAll(filepath.Join(iommuGroupPath, "devices"), mkdirPerm))
+
+	devicePath := filepath.Join(s.devicesPath, pciAddr)
+	require.NoError(t, os.Symlink(devicePath, filepath.Join(iommuGroupPath, "devices", pciAddr)))
+	require.NoError(t, os.Symlink(iommuGroupPath, filepath.Join(devicePath, "iommu_group")))
+}
+
+func (s *sysfs) createVF(t *testing.T, pfPCIAddr string, vfIndex int, vfPCIAddr string) {
+	s.createDevice(t, vfPCIAddr)
+
+	// real sysfs has relative virtfnN, physfn links
+	pfPath := filepath.Join(s.devicesPath, pfPCIAddr)
+	require.NoError(t, os.Symlink(filepath.Join("..", vfPCIAddr), filepath.Join(pfPath, "virtfn"+strconv.Itoa(vfIndex))))
+	vfPath := filepath.Join(s.devicesPath, vfPCIAddr)
+	require.NoError(t, os.Symlink(filepath.Join("..", pfPCIAddr), filepath.Join(vfPath, "physfn")))
+}
+
+func (s *sysfs) bindDriver(t *testing.T, pciAddr, driver string) {
+	driverPath := filepath.Join(s.driversPath, driver)
+	require.NoError(t, os.MkdirAll(driverPath, mkdirPerm))
+
+	devicePath := filepath.Join(s.devicesPath, pciAddr)
+	require.NoError(t, os.Symlink(devicePath, filepath.Join(driverPath, pciAddr)))
+	require.NoError(t, os.Symlink(driverPath, filepath.Join(devicePath, "driver")))
+}
+
+func (s *sysfs) unbindDriver(t *testing.T, pciAddr string) {
+	devicePath := filepath.Join(s.devicesPath, pciAddr)
+	driverPath, err := filepath.EvalSymlinks(filepath.Join(devicePath, "driver"))
+	require.NoError(t, err)
+
+	require.NoError(t, os.Remove(filepath.Join(driverPath, pciAddr)))
+	require.NoError(t, os.Remove(filepath.Join(devicePath, "driver")))
+}
+
+// runFakeKernel simulates the kernel handling drivers_probe, driver bind and unbind writes for the device until ctx
+// is done, drivers_probe binds vfio-pci driver
+func (s *sysfs) runFakeKernel(ctx context.Context, pciAddr string) {
+	devicePath := filepath.Join(s.devicesPath, pciAddr)
+	unbind := func(driver string) {
+		if driverPath, err := filepath.EvalSymlinks(filepath.Join(devicePath, "driver")); err == nil &&
+			(driver == "" || filepath.Base(driverPath) == driver) {
+			_ = os.Remove(filepath.Join(driverPath, pciAddr))
+			_ = os.Remove(filepath.Join(devicePath, "driver"))
+		}
+	}
+	bind := func(driver string) {
+		unbind("")
+		driverPath := filepath.Join(s.driversPath, driver)
+		_ = os.MkdirAll(driverPath, mkdirPerm)
+		_ = os.Symlink(devicePath, filepath.Join(driverPath, pciAddr))
+		_ = os.Symlink(driverPath, filepath.Join(devicePath, "driver"))
+	}
+	// consume returns true if the device address has been written to the file and clears it
+	consume := func(path string) bool {
+		if data, err := ioutil.ReadFile(filepath.Clean(path)); err != nil || string(data) != pciAddr {
+			return false
+		}
+		return ioutil.WriteFile(path, nil, filePerm) == nil
+	}
+
+	go func() {
+		for {
+			fInfos, _ := ioutil.ReadDir(s.driversPath)
+			for _, fInfo := range fInfos {
+				if consume(filepath.Join(s.driversPath, fInfo.Name(), "unbind")) {
+					unbind(fInfo.Name())
+				}
+				if consume(filepath.Join(s.driversPath, fInfo.Name(), "bind")) {
+					bind(fInfo.Name())
+				}
+			}
+			if consume(filepath.Join(filepath.Dir(s.driversPath), "drivers_probe")) {
+				bind("vfio-pci")
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(10 * time.Millisecond):
+			}
+		}
+	}()
+}
+
+func (s *sysfs) newPF(t *testing.T, pciAddr string) *pcifunction.PhysicalFunction {
+	pf, err := pcifunction.NewPhysicalFunction(pciAddr, s.devicesPath, s.driversPath)
+	require.NoError(t, err)
+	return pf
+}
+
+func TestFunction_GetLocalCPUs(t *testing.T) {
+	samples := []struct {
+		name    string
+		cpuList string
+		cpus    []int
+		err     error
+	}{
+		{
+			name:    "Single",
+			cpuList: "5\n",
+			cpus:    []int{5},
+		},
+		{
+			name:    "Range",
+			cpuList: "0-3\n",
+			cpus:    []int{0, 1, 2, 3},
+		},
+		{
+			name:    "Compound ranges",
+			cpuList: "0-3,8-11\n",
+			cpus:    []int{0, 1, 2, 3, 8, 9, 10, 11},
+		},
+		{
+			name:    "Mixed",
+			cpuList: "0,2-3,7\n",
+			cpus:    []int{0, 2, 3, 7},
+		},
+		{
+			name:    "Empty",
+			cpuList: "\n",
+			err:     pcifunction.ErrEmptyCPUList,
+		},
+		{
+			name:    "Reversed range",
+			cpuList: "3-0\n",
+			err:     pcifunction.ErrInvalidCPUList,
+		},
+		{
+			name:    "Malformed range",
+			cpuList: "0-a\n",
+			err:     pcifunction.ErrInvalidCPUList,
+		},
+		{
+			name:    "Too big range",
+			cpuList: "0-2147483647\n",
+			err:     pcifunction.ErrInvalidCPUList,
+		},
+		{
+			name:    "Too big CPU",
+			cpuList: "0,8192\n",
+			err:     pcifunction.ErrInvalidCPUList,
+		},
+	}
+
+	s := newSysfs(t)
+	s.createPF(t, pfPCIAddr, 1)
+	pf := s.newPF(t, pfPCIAddr)
+
+	for i := range samples {
+		sample := samples[i]
+		t.Run(sample.name, func(t *testing.T) {
+			s.writeFile(t, pfPCIAddr, "local_cpulist", sample.cpuList)
+
+			cpus, err := pf.GetLocalCPUs()
+			if sample.err != nil {
+				require.True(t, errors.Is(err, sample.err))
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, sample.cpus, cpus)
+		})
+	}
+
+	require.NoError(t, os.Remove(filepath.Join(s.devicesPath, pfPCIAddr, "local_cpulist")))
+
+	_, err := pf.GetLocalCPUs()
+	require.True(t, errors.Is(err, pcifunction.ErrAttributeNotFound))
+}
+
+func TestFunction_GetIOMMUGroupEndpoints(t *testing.T) {
+	s := newSysfs(t)
+
+	s.createPF(t, pfPCIAddr, 1)
+	s.writeFile(t, pfPCIAddr, "class", "0x020000\n")
+	s.addToIOMMUGroup(t, pfPCIAddr, 1)
+
+	s.createDevice(t, "0000:00:01.0")
+	s.writeFile(t, "0000:00:01.0", "class", "0x060400\n")
+	s.addToIOMMUGroup(t, "0000:00:01.0", 1)
+
+	s.createDevice(t, "0000:01:00.1")
+	s.writeFile(t, "0000:01:00.1", "class", "0x020000\n")
+	s.addToIOMMUGroup(t, "0000:01:00.1", 1)
+
+	pf := s.newPF(t, pfPCIAddr)
+
+	devices, err := pf.GetIOMMUGroupDevices()
+	require.NoError(t, err)
+	require.Equal(t, []string{"0000:00:01.0", pfPCIAddr, "0000:01:00.1"}, devices)
+
+	endpoints, err := pf.GetIOMMUGroupEndpoints()
+	require.NoError(t, err)
+	require.Equal(t, []string{pfPCIAddr, "0000:01:00.1"}, endpoints)
+
+	// device is hot unplugged, but the IOMMU group still has a link to it
+	require.NoError(t, os.RemoveAll(filepath.Join(s.devicesPath, "0000:01:00.1")))
+
+	endpoints, err = pf.GetIOMMUGroupEndpoints()
+	require.NoError(t, err)
+	require.Equal(t, []string{pfPCIAddr}, endpoints)
+}
+
+func TestFunction_IsNetworkDevice(t *testing.T) {
+	s := newSysfs(t)
+	s.createPF(t, pfPCIAddr, 1)
+	pf := s.newPF(t, pfPCIAddr)
+
+	_, err := pf.GetDeviceClass()
+	require.True(t, errors.Is(err, pcifunction.ErrAttributeNotFound))
+
+	s.writeFile(t, pfPCIAddr, "class", "0x020000\n")
+
+	class, err := pf.GetDeviceClass()
+	require.NoError(t, err)
+	require.Equal(t, "0x020000", class)
+
+	isNetwork, err := pf.IsNetworkDevice()
+	require.NoError(t, err)
+	require.True(t, isNetwork)
+
+	s.writeFile(t, pfPCIAddr, "class", "0x010802\n")
+
+	isNetwork, err = pf.IsNetworkDevice()
+	require.NoError(t, err)
+	require.False(t, isNetwork)
+}
+
+func TestFunction_IsBridge(t *testing.T) {
+	s := newSysfs(t)
+	s.createPF(t, pfPCIAddr, 1)
+	pf := s.newPF(t, pfPCIAddr)
+
+	_, err := pf.IsBridge()
+	require.True(t, errors.Is(err, pcifunction.ErrAttributeNotFound))
+
+	s.writeFile(t, pfPCIAddr, "class", "0x060400\n")
+
+	isBridge, err := pf.IsBridge()
+	require.NoError(t, err)
+	require.True(t, isBridge)
+
+	s.writeFile(t, pfPCIAddr, "class", "0x020000\n")
+
+	isBridge, err = pf.IsBridge()
+	require.NoError(t, err)
+	require.False(t, isBridge)
+}
+
+func TestFunction_GetBoundDriverStrict(t *testing.T) {
+	s := newSysfs(t)
+	s.createPF(t, pfPCIAddr, 1)
+	pf := s.newPF(t, pfPCIAddr)
+
+	driver, err := pf.GetBoundDriver()
+	require.NoError(t, err)
+	require.Empty(t, driver)
+
+	_, err = pf.GetBoundDriverStrict()
+	require.True(t, errors.Is(err, pcifunction.ErrNoDriverBound))
+
+	s.bindDriver(t, pfPCIAddr, "ixgbe")
+
+	driver, err = pf.GetBoundDriverStrict()
+	require.NoError(t, err)
+	require.Equal(t, "ixgbe", driver)
+}
+
+func TestFunction_GetBoundDriver_PathEscape(t *testing.T) {
+	s := newSysfs(t)
+	s.createPF(t, pfPCIAddr, 1)
+	pf := s.newPF(t, pfPCIAddr)
+
+	outOfRootDriverPath := filepath.Join(filepath.Dir(s.driversPath), "ixgbe")
+	require.NoError(t, os.MkdirAll(outOfRootDriverPath, mkdirPerm))
+	require.NoError(t, os.Symlink(outOfRootDriverPath, filepath.Join(s.devicesPath, pfPCIAddr, "driver")))
+
+	_, err := pf.GetBoundDriver()
+	require.True(t, errors.Is(err, pcifunction.ErrPathEscape))
+}
+
+func TestFunction_GetNetInterfaceStates(t *testing.T) {
+	s := newSysfs(t)
+	s.createPF(t, pfPCIAddr, 1)
+	s.writeFile(t, pfPCIAddr, "net/eth0/operstate", "up\n")
+	s.writeFile(t, pfPCIAddr, "net/eth1/operstate", "unknown\n")
+	pf := s.newPF(t, pfPCIAddr)
+
+	states, err := pf.GetNetInterfaceStates()
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{
+		"eth0": "up",
+		"eth1": "unknown",
+	}, states)
+}
+
+func TestFunction_GetDriverType(t *testing.T) {
+	s := newSysfs(t)
+
+	s.createPF(t, pfPCIAddr, 3)
+	s.createVF(t, pfPCIAddr, 0, "0000:01:00.1")
+	s.createVF(t, pfPCIAddr, 1, "0000:01:00.2")
+	s.createVF(t, pfPCIAddr, 2, "0000:01:00.3")
+
+	s.bindDriver(t, "0000:01:00.1", "ixgbevf")
+	s.bindDriver(t, "0000:01:00.2", "vfio-pci")
+
+	vfs := s.newPF(t, pfPCIAddr).GetVirtualFunctions()
+
+	for i, expected := range []sriov.DriverType{sriov.KernelDriver, sriov.VFIOPCIDriver, sriov.NoDriver} {
+		driverType, err := vfs[i].GetDriverType()
+		require.NoError(t, err)
+		require.Equal(t, expected, driverType)
+	}
+}
+
+func TestFunction_WaitForNetInterface(t *testing.T) {
+	s := newSysfs(t)
+	s.createPF(t, pfPCIAddr, 1)
+	pf := s.newPF(t, pfPCIAddr)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	_, err := pf.WaitForNetInterface(ctx)
+	require.True(t, errors.Is(err, context.DeadlineExceeded))
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		require.NoError(t, os.MkdirAll(filepath.Join(s.devicesPath, pfPCIAddr, "net", "eth1"), mkdirPerm))
+		require.NoError(t, os.MkdirAll(filepath.Join(s.devicesPath, pfPCIAddr, "net", "eth0"), mkdirPerm))
+	}()
+
+	ctx, cancel = context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	ifName, err := pf.WaitForNetInterface(ctx)
+	require.NoError(t, err)
+	require.Contains(t, []string{"eth0", "eth1"}, ifName)
+}
+
+func TestFunction_Attribute(t *testing.T) {
+	s := newSysfs(t)
+	s.createPF(t, pfPCIAddr, 1)
+	s.writeFile(t, pfPCIAddr, "power/control", "on\n")
+	pf := s.newPF(t, pfPCIAddr)
+
+	require.NoError(t, pf.WriteAttribute("power/control", "auto"))
+
+	value, err := pf.ReadAttribute("power/control")
+	require.NoError(t, err)
+	require.Equal(t, "auto", value)
+
+	_, err = pf.ReadAttribute("missing")
+	require.True(t, errors.Is(err, pcifunction.ErrAttributeNotFound))
+
+	for _, attr := range []string{"", "/etc/passwd", "../0000:01:00.1/class", "power/../../class"} {
+		_, err = pf.ReadAttribute(attr)
+		require.True(t, errors.Is(err, pcifunction.ErrInvalidAttribute), attr)
+
+		err = pf.WriteAttribute(attr, "1")
+		require.True(t, errors.Is(err, pcifunction.ErrInvalidAttribute), attr)
+	}
+}
+
+func TestFunction_IsVFIOGroupViable(t *testing.T) {
+	s := newSysfs(t)
+
+	s.createPF(t, pfPCIAddr, 1)
+	s.writeFile(t, pfPCIAddr, "class", "0x020000\n")
+	s.addToIOMMUGroup(t, pfPCIAddr, 1)
+	s.bindDriver(t, pfPCIAddr, "vfio-pci")
+
+	s.createDevice(t, "0000:00:01.0")
+	s.writeFile(t, "0000:00:01.0", "class", "0x060400\n")
+	s.addToIOMMUGroup(t, "0000:00:01.0", 1)
+	s.bindDriver(t, "0000:00:01.0", "pcieport")
+
+	s.createDevice(t, "0000:01:00.1")
+	s.writeFile(t, "0000:01:00.1", "class", "0x020000\n")
+	s.addToIOMMUGroup(t, "0000:01:00.1", 1)
+
+	pf := s.newPF(t, pfPCIAddr)
+
+	viable, boundEndpoints, err := pf.IsVFIOGroupViable()
+	require.NoError(t, err)
+	require.True(t, viable)
+	require.Empty(t, boundEndpoints)
+
+	s.bindDriver(t, "0000:01:00.1", "ixgbe")
+
+	viable, boundEndpoints, err = pf.IsVFIOGroupViable()
+	require.NoError(t, err)
+	require.False(t, viable)
+	require.Equal(t, []string{"0000:01:00.1"}, boundEndpoints)
+}
+
+func TestFunction_AssertIsVirtualFunction(t *testing.T) {
+	s := newSysfs(t)
+
+	s.createPF(t, pfPCIAddr, 1)
+	s.createVF(t, pfPCIAddr, 0, "0000:01:00.1")
+
+	pf := s.newPF(t, pfPCIAddr)
+	vf := pf.GetVirtualFunctions()[0]
+
+	require.NoError(t, vf.AssertIsVirtualFunction())
+	require.True(t, errors.Is(pf.AssertIsVirtualFunction(), pcifunction.ErrNotVirtualFunction))
+	require.True(t, errors.Is(pf.SetMSIXCount(1), pcifunction.ErrNotVirtualFunction))
+
+	require.NoError(t, os.RemoveAll(filepath.Join(s.devicesPath, "0000:01:00.1")))
+	require.True(t, errors.Is(vf.AssertIsVirtualFunction(), pcifunction.ErrDeviceNotFound))
+}
+
+func TestFunction_MSIXCount(t *testing.T) {
+	s := newSysfs(t)
+
+	s.createPF(t, pfPCIAddr, 1)
+	s.createVF(t, pfPCIAddr, 0, "0000:01:00.1")
+
+	vf := s.newPF(t, pfPCIAddr).GetVirtualFunctions()[0]
+
+	_, err := vf.GetMSIXCount()
+	require.True(t, errors.Is(err, pcifunction.ErrUnsupported))
+	require.True(t, errors.Is(vf.SetMSIXCount(1), pcifunction.ErrUnsupported))
+
+	s.writeFile(t, "0000:01:00.1", "sriov_vf_msix_count", "0\n")
+	s.writeFile(t, pfPCIAddr, "sriov_vf_total_msix", "16\n")
+
+	require.NoError(t, vf.SetMSIXCount(8))
+
+	msixCount, err := vf.GetMSIXCount()
+	require.NoError(t, err)
+	require.Equal(t, 8, msixCount)
+
+	require.True(t, errors.Is(vf.SetMSIXCount(17), pcifunction.ErrMSIXBudgetExceeded))
+}
+
+func TestFunction_GetModalias(t *testing.T) {
+	s := newSysfs(t)
+	s.createPF(t, pfPCIAddr, 1)
+	pf := s.newPF(t, pfPCIAddr)
+
+	_, err := pf.GetModalias()
+	require.True(t, errors.Is(err, pcifunction.ErrAttributeNotFound))
+
+	const modalias = "pci:v00008086d000010FBsv00008086sd00000003bc02sc00i00"
+	s.writeFile(t, pfPCIAddr, "modalias", modalias+"\n")
+
+	value, err := pf.GetModalias()
+	require.NoError(t, err)
+	require.Equal(t, modalias, value)
+}
+
+func TestFunction_GetRevisionID(t *testing.T) {
+	s := newSysfs(t)
+	s.createPF(t, pfPCIAddr, 1)
+	pf := s.newPF(t, pfPCIAddr)
+
+	_, err := pf.GetRevisionID()
+	require.True(t, errors.Is(err, pcifunction.ErrAttributeNotFound))
+
+	s.writeFile(t, pfPCIAddr, "revision", "0x01\n")
+
+	revision, err := pf.GetRevisionID()
+	require.NoError(t, err)
+	require.Equal(t, "0x01", revision)
+}
+
+func TestFunction_BindToVFIO(t *testing.T) {
+	s := newSysfs(t)
+	s.createPF(t, pfPCIAddr, 1)
+	s.writeFile(t, pfPCIAddr, "driver_override", "ixgbe\n")
+	s.bindDriver(t, pfPCIAddr, "vfio-pci")
+	pf := s.newPF(t, pfPCIAddr)
+
+	for i := 0; i < 2; i++ {
+		require.NoError(t, pf.BindToVFIO(context.Background()))
+
+		override, err := pf.ReadAttribute("driver_override")
+		require.NoError(t, err)
+		require.Equal(t, "vfio-pci", override)
+
+		driver, err := pf.GetBoundDriver()
+		require.NoError(t, err)
+		require.Equal(t, "vfio-pci", driver)
+	}
+}
+
+func TestFunction_BindToVFIO_NoDriversProbe(t *testing.T) {
+	s := newSysfs(t)
+	s.createPF(t, pfPCIAddr, 1)
+	s.writeFile(t, pfPCIAddr, "driver_override", "(null)\n")
+	pf := s.newPF(t, pfPCIAddr)
+
+	driversProbePath := filepath.Join(filepath.Dir(s.driversPath), "drivers_probe")
+	require.NoError(t, os.Remove(driversProbePath))
+
+	require.True(t, errors.Is(pf.BindToVFIO(context.Background()), pcifunction.ErrAttributeNotFound))
+
+	_, err := os.Stat(driversProbePath)
+	require.True(t, os.IsNotExist(err))
+}
+
+func TestFunction_RestoreOriginalDriver(t *testing.T) {
+	s := newSysfs(t)
+	s.createPF(t, pfPCIAddr, 1)
+	s.writeFile(t, pfPCIAddr, "driver_override", "(null)\n")
+	s.bindDriver(t, pfPCIAddr, "ixgbe")
+	pf := s.newPF(t, pfPCIAddr)
+
+	require.True(t, errors.Is(pf.RestoreOriginalDriver(context.Background()), pcifunction.ErrNoDriverBound))
+
+	// there is no kernel to probe the driver, so the bind wait fails
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	require.True(t, errors.Is(pf.BindToVFIO(ctx), context.DeadlineExceeded))
+
+	s.unbindDriver(t, pfPCIAddr)
+	s.bindDriver(t, pfPCIAddr, "vfio-pci")
+	require.NoError(t, pf.BindToVFIO(context.Background()))
+
+	s.unbindDriver(t, pfPCIAddr)
+	s.bindDriver(t, pfPCIAddr, "ixgbe")
+	require.NoError(t, pf.RestoreOriginalDriver(context.Background()))
+
+	override, err := pf.ReadAttribute("driver_override")
+	require.NoError(t, err)
+	require.Empty(t, override)
+
+	require.True(t, errors.Is(pf.RestoreOriginalDriver(context.Background()), pcifunction.ErrNoDriverBound))
+}
+
+func TestFunction_PrepareForVFIO(t *testing.T) {
+	s := newSysfs(t)
+	s.createPF(t, pfPCIAddr, 1)
+	s.writeFile(t, pfPCIAddr, "class", "0x020000\n")
+	s.writeFile(t, pfPCIAddr, "driver_override", "(null)\n")
+	s.addToIOMMUGroup(t, pfPCIAddr, 1)
+	s.bindDriver(t, pfPCIAddr, "ixgbe")
+	pf := s.newPF(t, pfPCIAddr)
+
+	// there is no kernel to probe the driver, so the bind wait fails and the original driver gets restored
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	require.True(t, errors.Is(pf.PrepareForVFIO(ctx), context.DeadlineExceeded))
+
+	override, err := pf.ReadAttribute("driver_override")
+	require.NoError(t, err)
+	require.Empty(t, override)
+
+	s.unbindDriver(t, pfPCIAddr)
+	s.bindDriver(t, pfPCIAddr, "vfio-pci")
+	require.NoError(t, pf.PrepareForVFIO(context.Background()))
+
+	override, err = pf.ReadAttribute("driver_override")
+	require.NoError(t, err)
+	require.Equal(t, "vfio-pci", override)
+}
+
+func TestFunction_PrepareForVFIO_Rollback(t *testing.T) {
+	s := newSysfs(t)
+	s.createPF(t, pfPCIAddr, 1)
+	s.writeFile(t, pfPCIAddr, "class", "0x020000\n")
+	s.writeFile(t, pfPCIAddr, "driver_override", "(null)\n")
+	s.addToIOMMUGroup(t, pfPCIAddr, 1)
+	s.bindDriver(t, pfPCIAddr, "ixgbe")
+	require.NoError(t, ioutil.WriteFile(filepath.Join(s.driversPath, "ixgbe", "bind"), nil, filePerm))
+
+	// another endpoint in the IOMMU group is bound to the kernel driver, so the group is not viable
+	s.createDevice(t, "0000:02:00.0")
+	s.writeFile(t, "0000:02:00.0", "class", "0x020000\n")
+	s.addToIOMMUGroup(t, "0000:02:00.0", 1)
+	s.bindDriver(t, "0000:02:00.0", "e1000e")
+
+	pf := s.newPF(t, pfPCIAddr)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	s.runFakeKernel(ctx, pfPCIAddr)
+
+	err := pf.PrepareForVFIO(ctx)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "not viable")
+	require.NotContains(t, err.Error(), "failed to restore")
+
+	driver, err := pf.GetBoundDriver()
+	require.NoError(t, err)
+	require.Equal(t, "ixgbe", driver)
+
+	override, err := pf.ReadAttribute("driver_override")
+	require.NoError(t, err)
+	require.Empty(t, override)
+
+	require.True(t, errors.Is(pf.RestoreOriginalDriver(ctx), pcifunction.ErrNoDriverBound))
+}
+
+func TestFunction_PrepareForVFIO_RollbackUnbound(t *testing.T) {
+	s := newSysfs(t)
+	s.createPF(t, pfPCIAddr, 1)
+	s.writeFile(t, pfPCIAddr, "class", "0x020000\n")
+	s.writeFile(t, pfPCIAddr, "driver_override", "(null)\n")
+	s.addToIOMMUGroup(t, pfPCIAddr, 1)
+
+	// another endpoint in the IOMMU group is bound to the kernel driver, so the group is not viable
+	s.createDevice(t, "0000:02:00.0")
+	s.writeFile(t, "0000:02:00.0", "class", "0x020000\n")
+	s.addToIOMMUGroup(t, "0000:02:00.0", 1)
+	s.bindDriver(t, "0000:02:00.0", "e1000e")
+
+	pf := s.newPF(t, pfPCIAddr)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	s.runFakeKernel(ctx, pfPCIAddr)
+
+	err := pf.PrepareForVFIO(ctx)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "not viable")
+	require.NotContains(t, err.Error(), "failed to restore")
+
+	override, err := pf.ReadAttribute("driver_override")
+	require.NoError(t, err)
+	require.Empty(t, override)
+
+	require.Eventually(t, func() bool {
+		driver, err := pf.GetBoundDriver()
+		return err == nil && driver == ""
+	}, 500*time.Millisecond, 10*time.Millisecond)
+}
+
+func TestFunction_WaitForVFIOBound(t *testing.T) {
+	s := newSysfs(t)
+	s.createPF(t, pfPCIAddr, 1)
+	s.bindDriver(t, pfPCIAddr, "ixgbe")
+	pf := s.newPF(t, pfPCIAddr)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	err := pf.WaitForVFIOBound(ctx)
+	require.True(t, errors.Is(err, context.DeadlineExceeded))
+	require.Contains(t, err.Error(), "ixgbe")
+
+	ctx, cancel = context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	// fake kernel rebinds the device to vfio-pci asynchronously
+	s.runFakeKernel(ctx, pfPCIAddr)
+
+	vfioDriverPath := filepath.Join(s.driversPath, "vfio-pci")
+	require.NoError(t, os.MkdirAll(vfioDriverPath, mkdirPerm))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(vfioDriverPath, "bind"), []byte(pfPCIAddr), filePerm))
+
+	require.NoError(t, pf.WaitForVFIOBound(ctx))
+}
+
+func TestFunction_SetNUMANode(t *testing.T) {
+	s := newSysfs(t)
+	s.createPF(t, pfPCIAddr, 1)
+	pf := s.newPF(t, pfPCIAddr)
+
+	require.True(t, errors.Is(pf.SetNUMANode(1), pcifunction.ErrUnsupported))
+
+	numaNodePath := filepath.Join(s.devicesPath, pfPCIAddr, "numa_node")
+	require.NoError(t, ioutil.WriteFile(numaNodePath, []byte("-1\n"), 0400))
+
+	require.True(t, errors.Is(pf.SetNUMANode(1), pcifunction.ErrUnsupported))
+
+	require.NoError(t, os.Chmod(numaNodePath, filePerm))
+	require.NoError(t, pf.SetNUMANode(1))
+
+	numaNode, err := pf.GetNUMANode()
+	require.NoError(t, err)
+	require.Equal(t, 1, numaNode)
+}
+
+func TestFunction_GetSriovCapacityInfo(t *testing.T) {
+	s := newSysfs(t)
+	s.createPF(t, pfPCIAddr, 1)
+	s.createVF(t, pfPCIAddr, 0, "0000:01:00.1")
+	pf := s.newPF(t, pfPCIAddr)
+
+	capacity, capable, err := pf.GetSriovCapacityInfo()
+	require.NoError(t, err)
+	require.True(t, capable)
+	require.Equal(t, 8, capacity)
+
+	capacity, capable, err = pf.GetVirtualFunctions()[0].GetSriovCapacityInfo()
+	require.NoError(t, err)
+	require.False(t, capable)
+	require.Equal(t, 0, capacity)
+
+	s.writeFile(t, pfPCIAddr, "sriov_totalvfs", "0\n")
+
+	capacity, capable, err = pf.GetSriovCapacityInfo()
+	require.NoError(t, err)
+	require.True(t, capable)
+	require.Equal(t, 0, capacity)
+}
+
+func TestFunction_GetNetInterfacesNamesByType(t *testing.T) {
+	s := newSysfs(t)
+	s.createPF(t, pfPCIAddr, 1)
+	s.writeFile(t, pfPCIAddr, "net/eth0/type", "1\n")
+	s.writeFile(t, pfPCIAddr, "net/ib0/type", "32\n")
+	s.writeFile(t, pfPCIAddr, "net/eth1/type", "1\n")
+	pf := s.newPF(t, pfPCIAddr)
+
+	ifNames, err := pf.GetNetInterfacesNames()
+	require.NoError(t, err)
+	require.Equal(t, []string{"eth0", "eth1", "ib0"}, ifNames)
+
+	ifNames, err = pf.GetNetInterfacesNamesByType(pcifunction.EthernetInterface)
+	require.NoError(t, err)
+	require.Equal(t, []string{"eth0", "eth1"}, ifNames)
+
+	ifNames, err = pf.GetNetInterfacesNamesByType(pcifunction.InfinibandInterface)
+	require.NoError(t, err)
+	require.Equal(t, []string{"ib0"}, ifNames)
+}
+
+func TestFunction_GetPrimaryNetInterface(t *testing.T) {
+	s := newSysfs(t)
+	s.createPF(t, pfPCIAddr, 1)
+	s.writeFile(t, pfPCIAddr, "net/eth0/ifindex", "5\n")
+	s.writeFile(t, pfPCIAddr, "net/ens1f0/ifindex", "4\n")
+	s.writeFile(t, pfPCIAddr, "net/ens1f0np0/ifindex", "4\n")
+
+	pf := s.newPF(t, pfPCIAddr)
+
+	ifNames, err := pf.GetNetInterfacesNames()
+	require.NoError(t, err)
+	require.Equal(t, []string{"ens1f0", "ens1f0np0", "eth0"}, ifNames)
+
+	ifName, err := pf.GetPrimaryNetInterface()
+	require.NoError(t, err)
+	require.Equal(t, "ens1f0", ifName)
+
+	pf, err = pcifunction.NewPhysicalFunction(pfPCIAddr, s.devicesPath, s.driversPath,
+		pcifunction.WithNetInterfacesDedup())
+	require.NoError(t, err)
+
+	ifNames, err = pf.GetNetInterfacesNames()
+	require.NoError(t, err)
+	require.Equal(t, []string{"ens1f0", "eth0"}, ifNames)
+}
+
+func TestFunction_GetAERStats(t *testing.T) {
+	s := newSysfs(t)
+	s.createPF(t, pfPCIAddr, 1)
+	pf := s.newPF(t, pfPCIAddr)
+
+	_, _, _, err := pf.GetAERStats()
+	require.True(t, errors.Is(err, pcifunction.ErrUnsupported))
+
+	s.writeFile(t, pfPCIAddr, "aer_dev_correctable", "RxErr 1\nBadTLP 2\nTOTAL_ERR_COR 3\n")
+	s.writeFile(t, pfPCIAddr, "aer_dev_fatal", "Undefined 0\nDLP 0\nTOTAL_ERR_FATAL 0\n")
+	s.writeFile(t, pfPCIAddr, "aer_dev_nonfatal", "Undefined 0\nUnsupReq 5\nTOTAL_ERR_NONFATAL 5\n")
+
+	correctable, fatal, nonfatal, err := pf.GetAERStats()
+	require.NoError(t, err)
+	require.Equal(t, uint64(3), correctable)
+	require.Equal(t, uint64(0), fatal)
+	require.Equal(t, uint64(5), nonfatal)
+}
+
+func TestFunction_CheckPFHealth(t *testing.T) {
+	s := newSysfs(t)
+	s.createPF(t, pfPCIAddr, 4)
+	s.writeFile(t, pfPCIAddr, "net/eth0/ifindex", "4\n")
+	s.writeFile(t, pfPCIAddr, "net/eth0/operstate", "up\n")
+	s.writeFile(t, pfPCIAddr, "net/eth1/ifindex", "5\n")
+	s.writeFile(t, pfPCIAddr, "net/eth1/operstate", "down\n")
+	s.addToIOMMUGroup(t, pfPCIAddr, 1)
+	pf := s.newPF(t, pfPCIAddr)
+
+	health, err := pf.CheckPFHealth()
+	require.NoError(t, err)
+	require.True(t, health.SriovCapable)
+	require.True(t, health.SriovEnabled)
+	require.NoError(t, health.SriovErr)
+	require.Equal(t, 4, health.ConfiguredVFs)
+	require.NoError(t, health.ConfiguredVFsErr)
+	require.True(t, health.LinkUp)
+	require.NoError(t, health.LinkErr)
+	require.True(t, health.IOMMUAvailable)
+	require.NoError(t, health.IOMMUErr)
+	require.Empty(t, health.Driver)
+	require.True(t, errors.Is(health.DriverErr, pcifunction.ErrNoDriverBound))
+
+	require.NoError(t, os.RemoveAll(filepath.Join(s.devicesPath, pfPCIAddr)))
+
+	_, err = pf.CheckPFHealth()
+	require.True(t, errors.Is(err, pcifunction.ErrDeviceNotFound))
+}
+
+func TestFunction_GetParentBridge(t *testing.T) {
+	s := newSysfs(t)
+
+	// real sysfs devices are links to the PCI hierarchy: /sys/devices/pci0000:00/0000:00:01.0/0000:01:00.0
+	rootPath := filepath.Join(filepath.Dir(s.devicesPath), "pci0000:00")
+	bridgePath := filepath.Join(rootPath, "0000:00:01.0")
+	pfPath := filepath.Join(bridgePath, pfPCIAddr)
+	require.NoError(t, os.MkdirAll(pfPath, mkdirPerm))
+	require.NoError(t, os.Symlink(bridgePath, filepath.Join(s.devicesPath, "0000:00:01.0")))
+	require.NoError(t, os.Symlink(pfPath, filepath.Join(s.devicesPath, pfPCIAddr)))
+	s.createPF(t, pfPCIAddr, 1)
+	s.writeFile(t, "0000:00:01.0", "sriov_totalvfs", "0")
+	s.writeFile(t, "0000:00:01.0", "sriov_numvfs", "0")
+
+	pf := s.newPF(t, pfPCIAddr)
+
+	bridge, err := pf.GetParentBridge()
+	require.NoError(t, err)
+	require.Equal(t, "0000:00:01.0", bridge)
+
+	bridgeFunction := s.newPF(t, "0000:00:01.0")
+
+	_, err = bridgeFunction.GetParentBridge()
+	require.True(t, errors.Is(err, pcifunction.ErrNoParentBridge))
+}
+
+func TestFunction_GetPhysicalFunctionPCIAddress(t *testing.T) {
+	s := newSysfs(t)
+	s.createPF(t, pfPCIAddr, 1)
+	s.createVF(t, pfPCIAddr, 0, "0000:01:00.1")
+	pf := s.newPF(t, pfPCIAddr)
+
+	physfn, err := os.Readlink(filepath.Join(s.devicesPath, "0000:01:00.1", "physfn"))
+	require.NoError(t, err)
+	require.False(t, filepath.IsAbs(physfn))
+
+	pfAddr, err := pf.GetVirtualFunctions()[0].GetPhysicalFunctionPCIAddress()
+	require.NoError(t, err)
+	require.Equal(t, pfPCIAddr, pfAddr)
+
+	_, err = pf.GetPhysicalFunctionPCIAddress()
+	require.Error(t, err)
+
+	// VF physfn still points to the PF, but the PF doesn't list the VF
+	vf := pf.GetVirtualFunctions()[0]
+	require.NoError(t, os.Remove(filepath.Join(s.devicesPath, pfPCIAddr, "virtfn0")))
+
+	_, err = vf.GetPhysicalFunctionPCIAddress()
+	require.True(t, errors.Is(err, pcifunction.ErrInconsistentSriovTopology))
+}
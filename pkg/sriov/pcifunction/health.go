@@ -0,0 +1,90 @@
+// Copyright (c) 2021 Doc.ai and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pcifunction
+
+import (
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	operStateFile = "operstate"
+	operStateUp   = "up"
+)
+
+// PFHealth is a readiness snapshot of the PCI function expected to be an SR-IOV PF. Each check failure is stored in
+// the corresponding *Err field instead of failing the whole check.
+type PFHealth struct {
+	SriovCapable bool
+	SriovEnabled bool
+	SriovErr     error
+
+	ConfiguredVFs    int
+	ConfiguredVFsErr error
+
+	LinkUp  bool
+	LinkErr error
+
+	IOMMUAvailable bool
+	IOMMUErr       error
+
+	Driver    string
+	DriverErr error
+}
+
+// CheckPFHealth returns f PFHealth, fails only if f device doesn't exist
+func (f *Function) CheckPFHealth() (*PFHealth, error) {
+	if !isFileExists(f.withDevicePath()) {
+		return nil, errors.Wrapf(ErrDeviceNotFound, "PCI device doesn't exist: %v", f.address)
+	}
+
+	health := new(PFHealth)
+
+	var capacity int
+	capacity, health.SriovCapable, health.SriovErr = f.GetSriovCapacityInfo()
+	health.SriovEnabled = capacity > 0
+
+	if health.SriovCapable {
+		var configuredVFs uint
+		configuredVFs, health.ConfiguredVFsErr = readUintFromFile(f.withDevicePath(configuredVFFile))
+		health.ConfiguredVFs = int(configuredVFs)
+	}
+
+	health.LinkUp, health.LinkErr = f.isLinkUp()
+
+	_, health.IOMMUErr = f.GetIOMMUGroup()
+	health.IOMMUAvailable = health.IOMMUErr == nil
+
+	health.Driver, health.DriverErr = f.GetBoundDriverStrict()
+
+	return health, nil
+}
+
+func (f *Function) isLinkUp() (bool, error) {
+	ifName, err := f.GetPrimaryNetInterface()
+	if err != nil {
+		return false, err
+	}
+
+	operState, err := f.readAttribute(filepath.Join(netInterfacesPath, ifName, operStateFile))
+	if err != nil {
+		return false, err
+	}
+
+	return operState == operStateUp, nil
+}
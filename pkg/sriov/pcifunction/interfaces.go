@@ -0,0 +1,57 @@
+// Copyright (c) 2021 Doc.ai and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pcifunction
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+// GetInterfaceToPCIMap returns net interface name -> PCI address map for all net interfaces of all PCI devices in
+// pciDevicesPath. Devices having multiple net interfaces have all of them in the map.
+func GetInterfaceToPCIMap(pciDevicesPath string) (map[string]string, error) {
+	fInfos, err := ioutil.ReadDir(pciDevicesPath)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read PCI devices directory: %v", pciDevicesPath)
+	}
+
+	ifPCIAddrs := map[string]string{}
+	for _, fInfo := range fInfos {
+		pciAddr := fInfo.Name()
+		if !validLongPCIAddr.MatchString(pciAddr) {
+			continue
+		}
+
+		ifInfos, err := ioutil.ReadDir(filepath.Join(pciDevicesPath, pciAddr, netInterfacesPath))
+		switch {
+		case os.IsNotExist(err):
+			// device has no net interfaces or has been removed
+			continue
+		case err != nil:
+			return nil, errors.Wrapf(err, "failed to read net directory for the device: %v", pciAddr)
+		}
+
+		for _, ifInfo := range ifInfos {
+			ifPCIAddrs[ifInfo.Name()] = pciAddr
+		}
+	}
+
+	return ifPCIAddrs, nil
+}
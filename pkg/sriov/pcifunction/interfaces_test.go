@@ -0,0 +1,50 @@
+// Copyright (c) 2021 Doc.ai and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//+build !windows
+
+package pcifunction_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov/pcifunction"
+)
+
+func TestGetInterfaceToPCIMap(t *testing.T) {
+	s := newSysfs(t)
+
+	s.createPF(t, pfPCIAddr, 1)
+	require.NoError(t, os.MkdirAll(filepath.Join(s.devicesPath, pfPCIAddr, "net", "eth0"), mkdirPerm))
+	require.NoError(t, os.MkdirAll(filepath.Join(s.devicesPath, pfPCIAddr, "net", "eth1"), mkdirPerm))
+
+	s.createDevice(t, "0000:01:00.1")
+	require.NoError(t, os.MkdirAll(filepath.Join(s.devicesPath, "0000:01:00.1", "net", "eth2"), mkdirPerm))
+
+	s.createDevice(t, "0000:00:01.0")
+
+	ifPCIAddrs, err := pcifunction.GetInterfaceToPCIMap(s.devicesPath)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{
+		"eth0": pfPCIAddr,
+		"eth1": pfPCIAddr,
+		"eth2": "0000:01:00.1",
+	}, ifPCIAddrs)
+}
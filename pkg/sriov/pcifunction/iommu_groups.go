@@ -0,0 +1,53 @@
+// Copyright (c) 2021 Doc.ai and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pcifunction
+
+import (
+	"io/ioutil"
+	"os"
+	"sort"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+// GetIOMMUGroups returns all IOMMU groups ids from iommuGroupsPath sorted in ascending order. Returns empty slice if
+// there are no IOMMU groups and ErrUnsupported if iommuGroupsPath doesn't exist (IOMMU is disabled).
+func GetIOMMUGroups(iommuGroupsPath string) ([]uint, error) {
+	fInfos, err := ioutil.ReadDir(iommuGroupsPath)
+	switch {
+	case os.IsNotExist(err):
+		return nil, errors.Wrapf(ErrUnsupported, "IOMMU groups directory doesn't exist: %v", iommuGroupsPath)
+	case err != nil:
+		return nil, errors.Wrapf(err, "failed to read IOMMU groups directory: %v", iommuGroupsPath)
+	}
+
+	iommuGroups := []uint{}
+	for _, fInfo := range fInfos {
+		iommuGroup, err := strconv.ParseUint(fInfo.Name(), 10, 0)
+		if err != nil {
+			continue
+		}
+		iommuGroups = append(iommuGroups, uint(iommuGroup))
+	}
+
+	sort.Slice(iommuGroups, func(i, k int) bool {
+		return iommuGroups[i] < iommuGroups[k]
+	})
+
+	return iommuGroups, nil
+}
@@ -0,0 +1,52 @@
+// Copyright (c) 2021 Doc.ai and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//+build !windows
+
+package pcifunction_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov/pcifunction"
+)
+
+func TestGetIOMMUGroups(t *testing.T) {
+	s := newSysfs(t)
+
+	iommuGroups, err := pcifunction.GetIOMMUGroups(s.iommuGroupsPath)
+	require.NoError(t, err)
+	require.Empty(t, iommuGroups)
+	require.NotNil(t, iommuGroups)
+
+	s.createDevice(t, pfPCIAddr)
+	s.addToIOMMUGroup(t, pfPCIAddr, 10)
+	s.createDevice(t, "0000:01:00.1")
+	s.addToIOMMUGroup(t, "0000:01:00.1", 2)
+	s.createDevice(t, "0000:00:01.0")
+	s.addToIOMMUGroup(t, "0000:00:01.0", 1)
+
+	iommuGroups, err = pcifunction.GetIOMMUGroups(s.iommuGroupsPath)
+	require.NoError(t, err)
+	require.Equal(t, []uint{1, 2, 10}, iommuGroups)
+
+	_, err = pcifunction.GetIOMMUGroups(filepath.Join(s.iommuGroupsPath, "not-exist"))
+	require.True(t, errors.Is(err, pcifunction.ErrUnsupported))
+}
@@ -0,0 +1,70 @@
+// Copyright (c) 2021 Doc.ai and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pcifunction
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	currentLinkSpeedFile = "current_link_speed"
+	maxLinkSpeedFile     = "max_link_speed"
+)
+
+// link speed is "<speed> GT/s" or "<speed> GT/s PCIe" depending on the kernel version, speed may be printed as "8" or
+// "8.0"
+var pcieGenerations = map[string]int{
+	"2.5":  1,
+	"5":    2,
+	"5.0":  2,
+	"8":    3,
+	"8.0":  3,
+	"16":   4,
+	"16.0": 4,
+	"32":   5,
+	"32.0": 5,
+	"64":   6,
+	"64.0": 6,
+}
+
+// GetPCIeGeneration returns f current and maximum PCIe link generations, returns ErrUnknownLinkSpeed if some link
+// speed can't be mapped to PCIe generation (e.g. it is "Unknown")
+func (f *Function) GetPCIeGeneration() (current, max int, err error) {
+	if current, err = f.readPCIeGeneration(currentLinkSpeedFile); err != nil {
+		return 0, 0, err
+	}
+	if max, err = f.readPCIeGeneration(maxLinkSpeedFile); err != nil {
+		return 0, 0, err
+	}
+	return current, max, nil
+}
+
+func (f *Function) readPCIeGeneration(name string) (int, error) {
+	linkSpeed, err := f.readAttribute(name)
+	if err != nil {
+		return 0, err
+	}
+
+	if fields := strings.Fields(linkSpeed); len(fields) >= 2 && fields[1] == "GT/s" {
+		if generation, ok := pcieGenerations[fields[0]]; ok {
+			return generation, nil
+		}
+	}
+	return 0, errors.Wrapf(ErrUnknownLinkSpeed, "%v is %q for the device: %v", name, linkSpeed, f.address)
+}
@@ -0,0 +1,50 @@
+// Copyright (c) 2021 Doc.ai and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//+build !windows
+
+package pcifunction_test
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov/pcifunction"
+)
+
+func TestFunction_GetPCIeGeneration(t *testing.T) {
+	s := newSysfs(t)
+	s.createPF(t, pfPCIAddr, 1)
+	pf := s.newPF(t, pfPCIAddr)
+
+	_, _, err := pf.GetPCIeGeneration()
+	require.True(t, errors.Is(err, pcifunction.ErrAttributeNotFound))
+
+	s.writeFile(t, pfPCIAddr, "current_link_speed", "5 GT/s\n")
+	s.writeFile(t, pfPCIAddr, "max_link_speed", "16.0 GT/s PCIe\n")
+
+	current, max, err := pf.GetPCIeGeneration()
+	require.NoError(t, err)
+	require.Equal(t, 2, current)
+	require.Equal(t, 4, max)
+
+	s.writeFile(t, pfPCIAddr, "current_link_speed", "Unknown\n")
+
+	_, _, err = pf.GetPCIeGeneration()
+	require.True(t, errors.Is(err, pcifunction.ErrUnknownLinkSpeed))
+}
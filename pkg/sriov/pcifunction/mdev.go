@@ -0,0 +1,97 @@
+// Copyright (c) 2021 Doc.ai and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pcifunction
+
+import (
+	"path/filepath"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+const (
+	mdevSupportedTypesPath = "mdev_supported_types"
+	mdevAvailableInstances = "available_instances"
+	mdevCreate             = "create"
+	mdevRemove             = "remove"
+	mdevUUIDLength         = 36
+)
+
+// GetAvailableInstances returns how many more mediated devices of the given type can be created on f. Returns
+// ErrUnsupported if f doesn't support mediated devices and ErrAttributeNotFound if f doesn't support mdevType.
+func (f *Function) GetAvailableInstances(mdevType string) (uint, error) {
+	if err := f.validateMdevType(mdevType); err != nil {
+		return 0, err
+	}
+
+	availableInstances, err := readUintFromFile(f.withDevicePath(mdevSupportedTypesPath, mdevType, mdevAvailableInstances))
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to get available instances for the device: %v %v", f.address, mdevType)
+	}
+
+	return availableInstances, nil
+}
+
+// CreateMdev creates mediated device of the given type with the given UUID on f. Returns ErrUnsupported if f doesn't
+// support mediated devices and ErrAttributeNotFound if f doesn't support mdevType.
+func (f *Function) CreateMdev(mdevType, mdevUUID string) error {
+	if err := f.validateMdevType(mdevType); err != nil {
+		return err
+	}
+	if err := validateMdevUUID(mdevUUID); err != nil {
+		return err
+	}
+
+	if err := f.writeAttribute(filepath.Join(mdevSupportedTypesPath, mdevType, mdevCreate), mdevUUID); err != nil {
+		return errors.Wrapf(err, "failed to create mediated device: %v %v", mdevType, mdevUUID)
+	}
+	return nil
+}
+
+// RemoveMdev removes f mediated device with the given UUID. Returns ErrUnsupported if f doesn't support mediated
+// devices and ErrAttributeNotFound if there is no such mediated device.
+func (f *Function) RemoveMdev(mdevUUID string) error {
+	if !isFileExists(f.withDevicePath(mdevSupportedTypesPath)) {
+		return errors.Wrapf(ErrUnsupported, "mediated devices are not supported for the device: %v", f.address)
+	}
+	if err := validateMdevUUID(mdevUUID); err != nil {
+		return err
+	}
+
+	if err := f.writeAttribute(filepath.Join(mdevUUID, mdevRemove), "1"); err != nil {
+		return errors.Wrapf(err, "failed to remove mediated device: %v", mdevUUID)
+	}
+	return nil
+}
+
+func (f *Function) validateMdevType(mdevType string) error {
+	if filepath.Base(mdevType) != mdevType || mdevType == "." || mdevType == ".." {
+		return errors.Wrapf(ErrInvalidAttribute, "invalid mdev type: %v", mdevType)
+	}
+	if !isFileExists(f.withDevicePath(mdevSupportedTypesPath)) {
+		return errors.Wrapf(ErrUnsupported, "mediated devices are not supported for the device: %v", f.address)
+	}
+	return nil
+}
+
+// validateMdevUUID accepts only the canonical xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx UUID format expected by the kernel
+func validateMdevUUID(mdevUUID string) error {
+	if _, err := uuid.Parse(mdevUUID); err != nil || len(mdevUUID) != mdevUUIDLength {
+		return errors.Errorf("invalid mediated device UUID: %v", mdevUUID)
+	}
+	return nil
+}
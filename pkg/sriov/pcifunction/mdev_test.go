@@ -0,0 +1,86 @@
+// Copyright (c) 2021 Doc.ai and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//+build !windows
+
+package pcifunction_test
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov/pcifunction"
+)
+
+const mdevType = "nvidia-63"
+
+func TestFunction_GetAvailableInstances(t *testing.T) {
+	s := newSysfs(t)
+	s.createPF(t, pfPCIAddr, 1)
+	pf := s.newPF(t, pfPCIAddr)
+
+	_, err := pf.GetAvailableInstances(mdevType)
+	require.True(t, errors.Is(err, pcifunction.ErrUnsupported))
+
+	s.writeFile(t, pfPCIAddr, "mdev_supported_types/"+mdevType+"/available_instances", "4\n")
+
+	availableInstances, err := pf.GetAvailableInstances(mdevType)
+	require.NoError(t, err)
+	require.Equal(t, uint(4), availableInstances)
+
+	_, err = pf.GetAvailableInstances("nvidia-64")
+	require.True(t, errors.Is(err, pcifunction.ErrAttributeNotFound))
+
+	_, err = pf.GetAvailableInstances("../" + mdevType)
+	require.True(t, errors.Is(err, pcifunction.ErrInvalidAttribute))
+}
+
+func TestFunction_CreateRemoveMdev(t *testing.T) {
+	s := newSysfs(t)
+	s.createPF(t, pfPCIAddr, 1)
+	pf := s.newPF(t, pfPCIAddr)
+
+	mdevUUID := uuid.New().String()
+
+	require.True(t, errors.Is(pf.CreateMdev(mdevType, mdevUUID), pcifunction.ErrUnsupported))
+	require.True(t, errors.Is(pf.RemoveMdev(mdevUUID), pcifunction.ErrUnsupported))
+
+	s.writeFile(t, pfPCIAddr, "mdev_supported_types/"+mdevType+"/create", "")
+
+	require.Error(t, pf.CreateMdev(mdevType, "not-uuid"))
+	require.Error(t, pf.CreateMdev(mdevType, "{"+mdevUUID+"}"))
+	require.True(t, errors.Is(pf.CreateMdev("nvidia-64", mdevUUID), pcifunction.ErrAttributeNotFound))
+
+	require.NoError(t, pf.CreateMdev(mdevType, mdevUUID))
+	data, err := ioutil.ReadFile(filepath.Join(s.devicesPath, pfPCIAddr, "mdev_supported_types", mdevType, "create"))
+	require.NoError(t, err)
+	require.Equal(t, mdevUUID, string(data))
+
+	require.True(t, errors.Is(pf.RemoveMdev(mdevUUID), pcifunction.ErrAttributeNotFound))
+
+	s.writeFile(t, pfPCIAddr, mdevUUID+"/remove", "")
+
+	require.Error(t, pf.RemoveMdev("../"+mdevUUID))
+	require.NoError(t, pf.RemoveMdev(mdevUUID))
+	data, err = ioutil.ReadFile(filepath.Join(s.devicesPath, pfPCIAddr, mdevUUID, "remove"))
+	require.NoError(t, err)
+	require.Equal(t, "1", string(data))
+}
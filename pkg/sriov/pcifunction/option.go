@@ -0,0 +1,35 @@
+// Copyright (c) 2021 Doc.ai and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pcifunction
+
+// Option is an option for NewPhysicalFunction
+type Option func(f *Function)
+
+// WithVFIODriver sets vfio driver name for the PF and its VFs, default is "vfio-pci"
+func WithVFIODriver(vfioDriver string) Option {
+	return func(f *Function) {
+		f.vfioDriver = vfioDriver
+	}
+}
+
+// WithNetInterfacesDedup makes GetNetInterfacesNames return only one name per net interface index, it is needed for
+// drivers transiently exposing both renamed and original interface names
+func WithNetInterfacesDedup() Option {
+	return func(f *Function) {
+		f.dedupNetInterfaces = true
+	}
+}
@@ -1,4 +1,4 @@
-// Copyright (c) 2020 Doc.ai and/or its affiliates.
+// Copyright (c) 2020-2021 Doc.ai and/or its affiliates.
 //
 // SPDX-License-Identifier: Apache-2.0
 //
@@ -18,6 +18,7 @@
 package pcifunction
 
 import (
+	"context"
 	"io/ioutil"
 	"os"
 	"path/filepath"
@@ -25,8 +26,11 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/pkg/errors"
+
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov"
 )
 
 // TODO: add unit tests with sriovtest.FileAPI
@@ -36,6 +40,10 @@ const (
 	totalVFFile           = "sriov_totalvfs"
 	configuredVFFile      = "sriov_numvfs"
 	virtualFunctionPrefix = "virtfn"
+	driversAutoprobeFile  = "sriov_drivers_autoprobe"
+	vfOffsetFile          = "sriov_offset"
+	vfStrideFile          = "sriov_stride"
+	vfsTeardownCheck      = 100 * time.Millisecond
 )
 
 var (
@@ -51,7 +59,7 @@ type PhysicalFunction struct {
 }
 
 // NewPhysicalFunction returns a new PhysicalFunction
-func NewPhysicalFunction(pciAddress, pciDevicesPath, pciDriversPath string) (*PhysicalFunction, error) {
+func NewPhysicalFunction(pciAddress, pciDevicesPath, pciDriversPath string, options ...Option) (*PhysicalFunction, error) {
 	var bdfPCIAddress string
 	switch {
 	case validLongPCIAddr.MatchString(pciAddress):
@@ -63,11 +71,10 @@ func NewPhysicalFunction(pciAddress, pciDevicesPath, pciDriversPath string) (*Ph
 	}
 
 	pciDevicePath := filepath.Join(pciDevicesPath, bdfPCIAddress)
-	if !isFileExists(pciDevicePath) {
-		return nil, errors.Errorf("PCI device doesn't exist: %v", bdfPCIAddress)
-	}
-
 	if !isFileExists(filepath.Join(pciDevicePath, totalVFFile)) {
+		if !isFileExists(pciDevicePath) {
+			return nil, errors.Wrapf(ErrDeviceNotFound, "PCI device doesn't exist: %v", bdfPCIAddress)
+		}
 		return nil, errors.Errorf("PCI device is not SR-IOV capable: %v", bdfPCIAddress)
 	}
 
@@ -76,8 +83,13 @@ func NewPhysicalFunction(pciAddress, pciDevicesPath, pciDriversPath string) (*Ph
 			address:        pciAddress,
 			pciDevicesPath: pciDevicesPath,
 			pciDriversPath: pciDriversPath,
+			vfioDriver:     string(sriov.VFIOPCIDriver),
 		},
 	}
+	for _, option := range options {
+		option(&pf.Function)
+	}
+
 	if err := pf.createVirtualFunctions(); err != nil {
 		return nil, err
 	}
@@ -87,17 +99,458 @@ func NewPhysicalFunction(pciAddress, pciDevicesPath, pciDriversPath string) (*Ph
 	return pf, nil
 }
 
-// GetVirtualFunctions returns pf virtual functions
+// GetConfiguredVirtualFunctionsNumberOrZero returns configured VFs number of the PF with the given PCI address, unlike
+// PhysicalFunction it doesn't require PF to exist and returns 0 if there is no PF or PF sriov_numvfs yet
+func GetConfiguredVirtualFunctionsNumberOrZero(pciDevicesPath, pfPCIAddr string) (int, error) {
+	vfsCount, err := readUintFromFile(filepath.Join(pciDevicesPath, pfPCIAddr, configuredVFFile))
+	switch {
+	case errors.Is(err, ErrAttributeNotFound):
+		return 0, nil
+	case err != nil:
+		return 0, errors.Wrapf(err, "failed to get configured VFs number for the device: %v", pfPCIAddr)
+	}
+	return int(vfsCount), nil
+}
+
+// VirtualFunctionsOrder is an order of the virtual functions list
+type VirtualFunctionsOrder int
+
+const (
+	// ByIndex orders virtual functions by VF index, the same as kernel virtfnN numbering
+	ByIndex VirtualFunctionsOrder = iota
+	// ByPCIAddress orders virtual functions by PCI address
+	ByPCIAddress
+)
+
+// GetVirtualFunctions returns pf virtual functions ordered by VF index
 func (pf *PhysicalFunction) GetVirtualFunctions() []*Function {
 	vfs := make([]*Function, len(pf.virtualFunctions))
 	copy(vfs, pf.virtualFunctions)
 	return vfs
 }
 
+// GetVirtualFunctionsStrict returns pf virtual functions ordered by VF index, unlike GetVirtualFunctions it fails if
+// some VF device directory doesn't exist
+func (pf *PhysicalFunction) GetVirtualFunctionsStrict() ([]*Function, error) {
+	for _, vf := range pf.virtualFunctions {
+		if !isFileExists(vf.withDevicePath()) {
+			return nil, errors.Wrapf(ErrDeviceNotFound, "virtual function device doesn't exist: %v", vf.address)
+		}
+	}
+	return pf.GetVirtualFunctions(), nil
+}
+
+// GetVirtualFunctionsOrdered returns pf virtual functions in the given order
+func (pf *PhysicalFunction) GetVirtualFunctionsOrdered(order VirtualFunctionsOrder) ([]*Function, error) {
+	vfs := pf.GetVirtualFunctions()
+	switch order {
+	case ByIndex:
+	case ByPCIAddress:
+		sort.Slice(vfs, func(i, k int) bool {
+			return vfs[i].address < vfs[k].address
+		})
+	default:
+		return nil, errors.Errorf("virtual functions order is not supported: %v", order)
+	}
+	return vfs, nil
+}
+
+// GetVirtualFunctionsByNUMANode returns pf virtual functions grouped by NUMA node, VFs without NUMA affinity are
+// grouped under the NoNUMANode key. VFs removed during the call are skipped.
+func (pf *PhysicalFunction) GetVirtualFunctionsByNUMANode() (map[int][]*Function, error) {
+	vfs := map[int][]*Function{}
+	for _, vf := range pf.virtualFunctions {
+		numaNode, err := vf.GetNUMANode()
+		switch {
+		case err != nil && vf.isRemoved():
+			continue
+		case err != nil:
+			return nil, err
+		}
+		vfs[numaNode] = append(vfs[numaNode], vf)
+	}
+	return vfs, nil
+}
+
+// GetKernelBoundVirtualFunctions returns pf virtual functions bound to some kernel driver, i.e. bound to any driver
+// except vfio driver
+func (pf *PhysicalFunction) GetKernelBoundVirtualFunctions() ([]*Function, error) {
+	var vfs []*Function
+	for _, vf := range pf.virtualFunctions {
+		switch driverType, err := vf.GetDriverType(); {
+		case err != nil:
+			return nil, err
+		case driverType != sriov.KernelDriver:
+			continue
+		}
+		vfs = append(vfs, vf)
+	}
+	return vfs, nil
+}
+
+// GetVFIOBoundVirtualFunctions returns pf virtual functions bound to vfio driver, i.e. ready for passthrough
+func (pf *PhysicalFunction) GetVFIOBoundVirtualFunctions() ([]*Function, error) {
+	var vfs []*Function
+	for _, vf := range pf.virtualFunctions {
+		switch driverType, err := vf.GetDriverType(); {
+		case err != nil:
+			return nil, err
+		case driverType != sriov.VFIOPCIDriver:
+			continue
+		}
+		vfs = append(vfs, vf)
+	}
+	return vfs, nil
+}
+
+// GetPassthroughableVirtualFunctions returns pf virtual functions which can be isolated for passthrough: all the other
+// endpoints in the VF IOMMU group are either bound to vfio driver or not bound to any driver. VF itself may be bound to
+// any driver, since it gets rebound to vfio driver for passthrough. PCI bridges in the group are not checked, see
+// GetIOMMUGroupEndpoints.
+func (pf *PhysicalFunction) GetPassthroughableVirtualFunctions() ([]*Function, error) {
+	var vfs []*Function
+	for _, vf := range pf.virtualFunctions {
+		_, boundEndpoints, err := vf.IsVFIOGroupViable()
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case len(boundEndpoints) == 0:
+		case len(boundEndpoints) == 1 && boundEndpoints[0] == vf.address:
+		default:
+			continue
+		}
+		vfs = append(vfs, vf)
+	}
+	return vfs, nil
+}
+
+// VirtualFunctionDetail describes PF virtual function state
+type VirtualFunctionDetail struct {
+	// Index is the VF virtfnN index
+	Index      int
+	PCIAddress string
+	Driver     string
+	// Free is true if VF is not used by the host: it is unbound or bound to vfio driver
+	Free bool
+}
+
+// GetVirtualFunctionsDetailed returns pf virtual functions details ordered by VF index
+func (pf *PhysicalFunction) GetVirtualFunctionsDetailed() ([]*VirtualFunctionDetail, error) {
+	var details []*VirtualFunctionDetail
+	for _, vf := range pf.virtualFunctions {
+		driver, err := vf.GetBoundDriver()
+		if err != nil {
+			return nil, err
+		}
+		details = append(details, &VirtualFunctionDetail{
+			Index:      vf.vfIndex,
+			PCIAddress: vf.address,
+			Driver:     driver,
+			Free:       pf.isFreeDriver(driver),
+		})
+	}
+	return details, nil
+}
+
+// DetectVFCountDrift returns map of PF PCI address -> desired minus configured VFs number for the PFs in desired:
+// positive drift means VFs should be added, negative - removed. PFs missing in pciDevicesPath are not included in the
+// drift, they are returned in MultiError with ErrDeviceNotFound together with the drift of the other PFs.
+func DetectVFCountDrift(pciDevicesPath string, desired map[string]int) (map[string]int, error) {
+	drift := map[string]int{}
+	errs := MultiError{}
+	for pfPCIAddr, desiredVFsCount := range desired {
+		if !isFileExists(filepath.Join(pciDevicesPath, pfPCIAddr)) {
+			errs[pfPCIAddr] = errors.Wrapf(ErrDeviceNotFound, "PCI device doesn't exist: %v", pfPCIAddr)
+			continue
+		}
+
+		vfsCount, err := GetConfiguredVirtualFunctionsNumberOrZero(pciDevicesPath, pfPCIAddr)
+		if err != nil {
+			errs[pfPCIAddr] = err
+			continue
+		}
+		drift[pfPCIAddr] = desiredVFsCount - vfsCount
+	}
+
+	if len(errs) > 0 {
+		return drift, errs
+	}
+	return drift, nil
+}
+
+// GetFreeVirtualFunctionsByPF returns map of PF PCI address -> number of the PF free virtual functions for the given
+// PFs, see VirtualFunctionDetail for the free VF definition. PFs without VFs are skipped. If some PFs fail, returns the
+// successful ones together with MultiError of the failed PFs.
+func GetFreeVirtualFunctionsByPF(pfs []*PhysicalFunction) (map[string]int, error) {
+	freeVFs := map[string]int{}
+	errs := MultiError{}
+	for _, pf := range pfs {
+		if len(pf.virtualFunctions) == 0 {
+			continue
+		}
+
+		details, err := pf.GetVirtualFunctionsDetailed()
+		if err != nil {
+			errs[pf.address] = err
+			continue
+		}
+
+		freeVFs[pf.address] = 0
+		for _, detail := range details {
+			if detail.Free {
+				freeVFs[pf.address]++
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return freeVFs, errs
+	}
+	return freeVFs, nil
+}
+
+func (pf *PhysicalFunction) isFreeDriver(driver string) bool {
+	return driver == "" || driver == pf.vfioDriver
+}
+
+// GetVirtualFunctionPCIAddress computes PCI address of the pf VF with the given index from the pf SR-IOV offset and
+// stride, without reading virtfnN links
+func (pf *PhysicalFunction) GetVirtualFunctionPCIAddress(vfIndex int) (string, error) {
+	if vfIndex < 0 {
+		return "", errors.Errorf("invalid VF index for the device: %v %v", pf.address, vfIndex)
+	}
+
+	domain, routingID, err := parsePCIAddress(pf.address)
+	if err != nil {
+		return "", err
+	}
+
+	offset, err := readUintFromFile(pf.withDevicePath(vfOffsetFile))
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to get VF offset for the device: %v", pf.address)
+	}
+
+	stride, err := readUintFromFile(pf.withDevicePath(vfStrideFile))
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to get VF stride for the device: %v", pf.address)
+	}
+
+	return formatPCIAddress(domain, routingID+offset+stride*uint(vfIndex))
+}
+
+// ValidateVirtualFunctionsLayout checks that the pf virtfnN links point to the PCI addresses computed from the pf
+// SR-IOV offset and stride, returns ErrInvalidVFLayout on mismatch
+func (pf *PhysicalFunction) ValidateVirtualFunctionsLayout() error {
+	vfDirs, err := filepath.Glob(pf.withDevicePath(virtualFunctionPrefix + "*"))
+	if err != nil {
+		return errors.Wrapf(err, "failed to find virtual function directories for the device: %v", pf.address)
+	}
+
+	for _, vfDir := range vfDirs {
+		vfIndex, err := strconv.Atoi(strings.TrimPrefix(filepath.Base(vfDir), virtualFunctionPrefix))
+		if err != nil {
+			return errors.Wrapf(ErrInvalidVFLayout, "invalid virtual function directory: %v", vfDir)
+		}
+
+		linkName, err := os.Readlink(vfDir)
+		if err != nil {
+			return errors.Wrapf(err, "invalid virtual function directory: %v", vfDir)
+		}
+
+		vfPCIAddr, err := pf.GetVirtualFunctionPCIAddress(vfIndex)
+		if err != nil {
+			return err
+		}
+
+		actual, err := normalizePCIAddress(filepath.Base(linkName))
+		if err != nil {
+			return errors.Wrapf(ErrInvalidVFLayout, "VF %d of the device %v has invalid PCI address: %v",
+				vfIndex, pf.address, filepath.Base(linkName))
+		}
+
+		if actual != vfPCIAddr {
+			return errors.Wrapf(ErrInvalidVFLayout, "VF %d of the device %v is %v, expected %v",
+				vfIndex, pf.address, actual, vfPCIAddr)
+		}
+	}
+
+	return nil
+}
+
+// GetVFTotalMSIX returns number of MSI-X vectors the pf can assign to its VFs, returns ErrUnsupported if the kernel or
+// the device doesn't support dynamic MSI-X vectors assignment
+func (pf *PhysicalFunction) GetVFTotalMSIX() (int, error) {
+	totalMSIX, err := readUintFromFile(pf.withDevicePath(vfTotalMSIXFile))
+	switch {
+	case errors.Is(err, ErrAttributeNotFound):
+		return 0, errors.Wrapf(ErrUnsupported, "VFs MSI-X budget is not supported for the device: %v", pf.address)
+	case err != nil:
+		return 0, errors.Wrapf(err, "failed to get VFs MSI-X budget for the device: %v", pf.address)
+	}
+	return int(totalMSIX), nil
+}
+
+// DistributeMSIXEvenly divides the pf VFs MSI-X budget evenly between the pf VFs, the remainder is left unassigned.
+// Returns ErrMSIXBudgetExceeded if the budget is less than the VFs number. VF MSI-X count can be changed only while
+// VF has no driver bound.
+func (pf *PhysicalFunction) DistributeMSIXEvenly() error {
+	if len(pf.virtualFunctions) == 0 {
+		return nil
+	}
+
+	totalMSIX, err := pf.GetVFTotalMSIX()
+	if err != nil {
+		return err
+	}
+
+	msixCount := totalMSIX / len(pf.virtualFunctions)
+	if msixCount == 0 {
+		return errors.Wrapf(ErrMSIXBudgetExceeded, "VFs MSI-X budget is less than VFs number for the device: %v %v < %v",
+			pf.address, totalMSIX, len(pf.virtualFunctions))
+	}
+
+	for _, vf := range pf.virtualFunctions {
+		if err := vf.SetMSIXCount(msixCount); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// IsSriovDriversAutoprobeEnabled returns true if kernel automatically probes drivers for the newly created pf VFs.
+// Returns ErrUnsupported if the kernel doesn't support disabling autoprobe.
+func (pf *PhysicalFunction) IsSriovDriversAutoprobeEnabled() (bool, error) {
+	autoprobe, err := pf.readAttribute(driversAutoprobeFile)
+	switch {
+	case errors.Is(err, ErrAttributeNotFound):
+		return false, errors.Wrapf(ErrUnsupported, "VFs drivers autoprobe is not supported for the device: %v", pf.address)
+	case err != nil:
+		return false, err
+	}
+	return autoprobe != "0", nil
+}
+
+// SetSriovDriversAutoprobe enables or disables automatic drivers probing for the newly created pf VFs. It affects
+// only VFs created after the call, so if VFs should come up unbound (e.g. to be bound to vfio-pci later), autoprobe
+// should be disabled before creating them. Returns ErrUnsupported if the kernel doesn't support disabling autoprobe.
+func (pf *PhysicalFunction) SetSriovDriversAutoprobe(enabled bool) error {
+	autoprobe := "0"
+	if enabled {
+		autoprobe = "1"
+	}
+
+	err := pf.writeAttribute(driversAutoprobeFile, autoprobe)
+	if errors.Is(err, ErrAttributeNotFound) {
+		return errors.Wrapf(ErrUnsupported, "VFs drivers autoprobe is not supported for the device: %v", pf.address)
+	}
+	return err
+}
+
+// GetSriovNumVFs returns pf configured VFs number, it is the pf sriov_numvfs value
+func (pf *PhysicalFunction) GetSriovNumVFs() (int, error) {
+	vfsCount, err := readUintFromFile(pf.withDevicePath(configuredVFFile))
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to get configured VFs number for the PCI device: %v", pf.address)
+	}
+	return int(vfsCount), nil
+}
+
+// WaitForZeroVFs waits until pf has no VFs: sriov_numvfs is 0 and all virtfnN links are removed. VFs teardown is
+// asynchronous, so it should be called after writing 0 to sriov_numvfs before configuring VFs again.
+func (pf *PhysicalFunction) WaitForZeroVFs(ctx context.Context) error {
+	for {
+		vfsCount, err := pf.GetSriovNumVFs()
+		vfDirs, _ := filepath.Glob(pf.withDevicePath(virtualFunctionPrefix + "*"))
+		if err == nil && vfsCount == 0 && len(vfDirs) == 0 {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return errors.Wrapf(ctx.Err(), "VFs are not removed for the device: %v, VFs number: %v, VF links: %v",
+				pf.address, vfsCount, len(vfDirs))
+		case <-time.After(vfsTeardownCheck):
+		}
+	}
+}
+
+// GetSriovTotalVFs returns pf VFs capacity, it is the pf sriov_totalvfs value. It is the same as capacity returned by
+// GetSriovCapacityInfo.
+func (pf *PhysicalFunction) GetSriovTotalVFs() (int, error) {
+	capacity, _, err := pf.GetSriovCapacityInfo()
+	return capacity, err
+}
+
+// EnsureVirtualFunctions idempotently sets pf configured VFs number to vfsCount and reloads pf VFs, nonzero vfsCount is
+// validated against pf VFs capacity:
+// * if vfsCount VFs are already configured, does nothing;
+// * if no VFs are configured, creates vfsCount VFs;
+// * if other number of VFs is configured, deletes all VFs first, because kernel doesn't allow to change nonzero
+//   configured VFs number directly. VFs deletion is asynchronous, so it waits with WaitForZeroVFs until ctx is done.
+func (pf *PhysicalFunction) EnsureVirtualFunctions(ctx context.Context, vfsCount int) error {
+	if vfsCount != 0 {
+		capacity, err := pf.GetSriovTotalVFs()
+		if err != nil {
+			return err
+		}
+		if err := validateVFsCount(vfsCount, capacity); err != nil {
+			return errors.Wrapf(err, "PCI device: %v", pf.address)
+		}
+	}
+
+	configuredVFsCount, err := pf.GetSriovNumVFs()
+	switch {
+	case err != nil:
+		return err
+	case configuredVFsCount == vfsCount:
+		return nil
+	}
+
+	// VFs can be already deleted even if setting VFs number fails, so VFs are reloaded in any case
+	err = pf.setVirtualFunctionsNumber(ctx, configuredVFsCount, vfsCount)
+
+	pf.virtualFunctions = nil
+	if loadErr := pf.loadVirtualFunctions(); err == nil {
+		err = loadErr
+	}
+	return err
+}
+
+func (pf *PhysicalFunction) setVirtualFunctionsNumber(ctx context.Context, configuredVFsCount, vfsCount int) error {
+	if configuredVFsCount > 0 {
+		if err := pf.writeAttribute(configuredVFFile, "0"); err != nil {
+			return errors.Wrapf(err, "failed to delete VFs for the PCI device: %v", pf.address)
+		}
+		if err := pf.WaitForZeroVFs(ctx); err != nil {
+			return err
+		}
+	}
+
+	if vfsCount > 0 {
+		if err := pf.writeAttribute(configuredVFFile, strconv.Itoa(vfsCount)); err != nil {
+			return errors.Wrapf(err, "failed to create VFs for the PCI device: %v", pf.address)
+		}
+	}
+	return nil
+}
+
+// validateVFsCount returns ErrInvalidVFsCount if VFs number to create is less than 1 or exceeds capacity
+func validateVFsCount(vfsCount, capacity int) error {
+	switch {
+	case vfsCount < 1:
+		return errors.Wrapf(ErrInvalidVFsCount, "VFs number should be at least 1: %v", vfsCount)
+	case vfsCount > capacity:
+		return errors.Wrapf(ErrInvalidVFsCount, "VFs number exceeds VFs capacity: %v > %v", vfsCount, capacity)
+	}
+	return nil
+}
+
 func (pf *PhysicalFunction) createVirtualFunctions() error {
-	switch vfsCount, err := readUintFromFile(pf.withDevicePath(configuredVFFile)); {
+	switch vfsCount, err := pf.GetSriovNumVFs(); {
 	case err != nil:
-		return errors.Wrapf(err, "failed to get configured VFs number for the PCI device: %v", pf.address)
+		return err
 	case vfsCount > 0:
 		return nil
 	}
@@ -122,27 +575,47 @@ func (pf *PhysicalFunction) loadVirtualFunctions() error {
 	}
 
 	sort.Slice(vfDirs, func(i, k int) bool {
-		iVFNum, _ := strconv.Atoi(strings.TrimPrefix(vfDirs[i], virtualFunctionPrefix))
-		kVFNum, _ := strconv.Atoi(strings.TrimPrefix(vfDirs[k], virtualFunctionPrefix))
+		iVFNum, _ := strconv.Atoi(strings.TrimPrefix(filepath.Base(vfDirs[i]), virtualFunctionPrefix))
+		kVFNum, _ := strconv.Atoi(strings.TrimPrefix(filepath.Base(vfDirs[k]), virtualFunctionPrefix))
 		return iVFNum < kVFNum
 	})
 
+	vfPCIAddrs := map[string]struct{}{}
 	for _, vfDir := range vfDirs {
+		vfIndex, err := strconv.Atoi(strings.TrimPrefix(filepath.Base(vfDir), virtualFunctionPrefix))
+		if err != nil {
+			continue
+		}
+
 		vfDirInfo, err := os.Lstat(vfDir)
-		if err != nil || vfDirInfo.Mode()&os.ModeSymlink == 0 {
+		if err != nil {
 			return errors.Wrapf(err, "invalid virtual function directory: %v", vfDir)
 		}
+		if vfDirInfo.Mode()&os.ModeSymlink == 0 {
+			return errors.Errorf("virtual function directory is not a symbolic link: %v", vfDir)
+		}
 
-		linkName, err := filepath.EvalSymlinks(vfDir)
+		// VF device directory can still be materializing right after VFs creation, so we don't evaluate the link
+		// but take the PCI address from the link target name
+		linkName, err := os.Readlink(vfDir)
 		if err != nil {
 			return errors.Wrapf(err, "invalid virtual function directory: %v", vfDir)
 		}
 
-		pf.virtualFunctions = append(pf.virtualFunctions, &Function{
-			address:        filepath.Base(linkName),
-			pciDevicesPath: pf.pciDevicesPath,
-			pciDriversPath: pf.pciDriversPath,
-		})
+		// link target can be in a non canonical form, VFs with not parsable addresses are skipped to keep the other VFs
+		// available
+		vfPCIAddr, err := normalizePCIAddress(filepath.Base(linkName))
+		if err != nil {
+			continue
+		}
+		if _, ok := vfPCIAddrs[vfPCIAddr]; ok {
+			continue
+		}
+		vfPCIAddrs[vfPCIAddr] = struct{}{}
+
+		vf := pf.newFunction(vfPCIAddr)
+		vf.vfIndex = vfIndex
+		pf.virtualFunctions = append(pf.virtualFunctions, vf)
 	}
 	return nil
 }
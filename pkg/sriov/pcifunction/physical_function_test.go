@@ -0,0 +1,687 @@
+// Copyright (c) 2021 Doc.ai and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//+build !windows
+
+package pcifunction_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov/pcifunction"
+)
+
+func TestNewPhysicalFunction_NotFound(t *testing.T) {
+	s := newSysfs(t)
+
+	_, err := pcifunction.NewPhysicalFunction(pfPCIAddr, s.devicesPath, s.driversPath)
+	require.True(t, errors.Is(err, pcifunction.ErrDeviceNotFound))
+
+	s.createDevice(t, pfPCIAddr)
+
+	_, err = pcifunction.NewPhysicalFunction(pfPCIAddr, s.devicesPath, s.driversPath)
+	require.Error(t, err)
+	require.False(t, errors.Is(err, pcifunction.ErrDeviceNotFound))
+}
+
+func TestGetConfiguredVirtualFunctionsNumberOrZero(t *testing.T) {
+	s := newSysfs(t)
+
+	vfsCount, err := pcifunction.GetConfiguredVirtualFunctionsNumberOrZero(s.devicesPath, pfPCIAddr)
+	require.NoError(t, err)
+	require.Equal(t, 0, vfsCount)
+
+	s.createPF(t, pfPCIAddr, 3)
+
+	vfsCount, err = pcifunction.GetConfiguredVirtualFunctionsNumberOrZero(s.devicesPath, pfPCIAddr)
+	require.NoError(t, err)
+	require.Equal(t, 3, vfsCount)
+
+	s.writeFile(t, pfPCIAddr, "sriov_numvfs", "three\n")
+
+	_, err = pcifunction.GetConfiguredVirtualFunctionsNumberOrZero(s.devicesPath, pfPCIAddr)
+	require.Error(t, err)
+}
+
+func TestPhysicalFunction_RelativeSymlinks(t *testing.T) {
+	s := newSysfs(t)
+
+	s.createPF(t, pfPCIAddr, 2)
+	s.createVF(t, pfPCIAddr, 0, "0000:01:00.1")
+	s.createVF(t, pfPCIAddr, 1, "0000:01:00.2")
+
+	vfPath := filepath.Join(s.devicesPath, "0000:01:00.1")
+	require.NoError(t, os.MkdirAll(filepath.Join(s.driversPath, "ixgbevf"), mkdirPerm))
+	require.NoError(t, os.Symlink(filepath.Join("..", "..", driversDir, "ixgbevf"), filepath.Join(vfPath, "driver")))
+	require.NoError(t, os.MkdirAll(filepath.Join(s.iommuGroupsPath, "5"), mkdirPerm))
+	require.NoError(t, os.Symlink(filepath.Join("..", "..", iommuGroupsDir, "5"), filepath.Join(vfPath, "iommu_group")))
+
+	pf := s.newPF(t, pfPCIAddr)
+
+	vfs := pf.GetVirtualFunctions()
+	require.Len(t, vfs, 2)
+	require.Equal(t, "0000:01:00.1", vfs[0].GetPCIAddress())
+	require.Equal(t, "0000:01:00.2", vfs[1].GetPCIAddress())
+
+	driver, err := vfs[0].GetBoundDriver()
+	require.NoError(t, err)
+	require.Equal(t, "ixgbevf", driver)
+
+	iommuGroup, err := vfs[0].GetIOMMUGroup()
+	require.NoError(t, err)
+	require.Equal(t, uint(5), iommuGroup)
+}
+
+func TestPhysicalFunction_GetKernelBoundVirtualFunctions(t *testing.T) {
+	s := newSysfs(t)
+
+	s.createPF(t, pfPCIAddr, 3)
+	s.createVF(t, pfPCIAddr, 0, "0000:01:00.1")
+	s.createVF(t, pfPCIAddr, 1, "0000:01:00.2")
+	s.createVF(t, pfPCIAddr, 2, "0000:01:00.3")
+
+	s.bindDriver(t, "0000:01:00.1", "ixgbevf")
+	s.bindDriver(t, "0000:01:00.2", "vfio-pci")
+
+	pf := s.newPF(t, pfPCIAddr)
+
+	vfs, err := pf.GetKernelBoundVirtualFunctions()
+	require.NoError(t, err)
+	require.Len(t, vfs, 1)
+	require.Equal(t, "0000:01:00.1", vfs[0].GetPCIAddress())
+}
+
+func TestPhysicalFunction_DuplicatedVirtualFunctions(t *testing.T) {
+	s := newSysfs(t)
+
+	s.createPF(t, pfPCIAddr, 2)
+	s.createVF(t, pfPCIAddr, 0, "0000:01:00.1")
+	require.NoError(t, os.Symlink(filepath.Join("..", "0000:01:00.1"), filepath.Join(s.devicesPath, pfPCIAddr, "virtfn1")))
+
+	pf := s.newPF(t, pfPCIAddr)
+
+	vfs := pf.GetVirtualFunctions()
+	require.Len(t, vfs, 1)
+	require.Equal(t, "0000:01:00.1", vfs[0].GetPCIAddress())
+}
+
+func TestPhysicalFunction_DanglingVirtualFunction(t *testing.T) {
+	s := newSysfs(t)
+
+	s.createPF(t, pfPCIAddr, 2)
+	s.createVF(t, pfPCIAddr, 0, "0000:01:00.1")
+	require.NoError(t, os.Symlink(filepath.Join("..", "0000:01:00.2"), filepath.Join(s.devicesPath, pfPCIAddr, "virtfn1")))
+
+	pf := s.newPF(t, pfPCIAddr)
+
+	vfs := pf.GetVirtualFunctions()
+	require.Len(t, vfs, 2)
+	require.Equal(t, "0000:01:00.2", vfs[1].GetPCIAddress())
+
+	_, err := pf.GetVirtualFunctionsStrict()
+	require.True(t, errors.Is(err, pcifunction.ErrDeviceNotFound))
+
+	s.createDevice(t, "0000:01:00.2")
+
+	vfs, err = pf.GetVirtualFunctionsStrict()
+	require.NoError(t, err)
+	require.Len(t, vfs, 2)
+}
+
+func TestPhysicalFunction_NonCanonicalVirtualFunctions(t *testing.T) {
+	s := newSysfs(t)
+
+	s.createPF(t, pfPCIAddr, 4)
+	s.createVF(t, pfPCIAddr, 0, "0000:01:00.1")
+	pfPath := filepath.Join(s.devicesPath, pfPCIAddr)
+	require.NoError(t, os.Symlink(filepath.Join("..", "invalid"), filepath.Join(pfPath, "virtfn1")))
+	require.NoError(t, os.Symlink(filepath.Join("..", "01:00.2"), filepath.Join(pfPath, "virtfn2")))
+	require.NoError(t, os.Symlink(filepath.Join("..", "0000:0A:00.1"), filepath.Join(pfPath, "virtfn3")))
+
+	pf := s.newPF(t, pfPCIAddr)
+
+	var vfPCIAddrs []string
+	for _, vf := range pf.GetVirtualFunctions() {
+		vfPCIAddrs = append(vfPCIAddrs, vf.GetPCIAddress())
+	}
+	require.Equal(t, []string{"0000:01:00.1", "0000:01:00.2", "0000:0a:00.1"}, vfPCIAddrs)
+
+	details, err := pf.GetVirtualFunctionsDetailed()
+	require.NoError(t, err)
+
+	var vfIndexes []int
+	for _, detail := range details {
+		vfIndexes = append(vfIndexes, detail.Index)
+	}
+	require.Equal(t, []int{0, 2, 3}, vfIndexes)
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, pf.Dump(buf))
+	require.Contains(t, buf.String(), "  VF 2 0000:01:00.2\n")
+	require.Contains(t, buf.String(), "  VF 3 0000:0a:00.1\n")
+}
+
+func TestPhysicalFunction_BrokenVirtualFunction(t *testing.T) {
+	s := newSysfs(t)
+
+	s.createPF(t, pfPCIAddr, 1)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(s.devicesPath, pfPCIAddr, "virtfn0"), nil, filePerm))
+
+	_, err := pcifunction.NewPhysicalFunction(pfPCIAddr, s.devicesPath, s.driversPath)
+	require.Error(t, err)
+}
+
+func TestPhysicalFunction_SriovDriversAutoprobe(t *testing.T) {
+	s := newSysfs(t)
+	s.createPF(t, pfPCIAddr, 1)
+	pf := s.newPF(t, pfPCIAddr)
+
+	_, err := pf.IsSriovDriversAutoprobeEnabled()
+	require.True(t, errors.Is(err, pcifunction.ErrUnsupported))
+	require.True(t, errors.Is(pf.SetSriovDriversAutoprobe(false), pcifunction.ErrUnsupported))
+
+	s.writeFile(t, pfPCIAddr, "sriov_drivers_autoprobe", "1\n")
+
+	enabled, err := pf.IsSriovDriversAutoprobeEnabled()
+	require.NoError(t, err)
+	require.True(t, enabled)
+
+	require.NoError(t, pf.SetSriovDriversAutoprobe(false))
+
+	enabled, err = pf.IsSriovDriversAutoprobeEnabled()
+	require.NoError(t, err)
+	require.False(t, enabled)
+}
+
+func TestPhysicalFunction_GetKernelBoundVirtualFunctions_CustomVFIODriver(t *testing.T) {
+	s := newSysfs(t)
+
+	s.createPF(t, pfPCIAddr, 2)
+	s.createVF(t, pfPCIAddr, 0, "0000:01:00.1")
+	s.createVF(t, pfPCIAddr, 1, "0000:01:00.2")
+
+	s.bindDriver(t, "0000:01:00.1", "vfio-pci")
+	s.bindDriver(t, "0000:01:00.2", "vfio-custom")
+
+	pf, err := pcifunction.NewPhysicalFunction(pfPCIAddr, s.devicesPath, s.driversPath,
+		pcifunction.WithVFIODriver("vfio-custom"))
+	require.NoError(t, err)
+
+	vfs, err := pf.GetKernelBoundVirtualFunctions()
+	require.NoError(t, err)
+	require.Len(t, vfs, 1)
+	require.Equal(t, "0000:01:00.1", vfs[0].GetPCIAddress())
+
+	vfs, err = pf.GetVFIOBoundVirtualFunctions()
+	require.NoError(t, err)
+	require.Len(t, vfs, 1)
+	require.Equal(t, "0000:01:00.2", vfs[0].GetPCIAddress())
+}
+
+func TestPhysicalFunction_GetPassthroughableVirtualFunctions(t *testing.T) {
+	s := newSysfs(t)
+
+	s.createPF(t, pfPCIAddr, 4)
+	for i, vfPCIAddr := range []string{"0000:01:00.1", "0000:01:00.2", "0000:01:00.3", "0000:01:00.4"} {
+		s.createVF(t, pfPCIAddr, i, vfPCIAddr)
+		s.writeFile(t, vfPCIAddr, "class", "0x020000\n")
+	}
+
+	// VF alone in the group
+	s.addToIOMMUGroup(t, "0000:01:00.1", 1)
+	s.bindDriver(t, "0000:01:00.1", "iavf")
+
+	// VFs sharing the group, both bound to the kernel driver
+	s.addToIOMMUGroup(t, "0000:01:00.2", 2)
+	s.addToIOMMUGroup(t, "0000:01:00.3", 2)
+	s.bindDriver(t, "0000:01:00.2", "iavf")
+	s.bindDriver(t, "0000:01:00.3", "iavf")
+
+	// VF sharing the group with a bridge
+	s.createDevice(t, "0000:00:01.0")
+	s.writeFile(t, "0000:00:01.0", "class", "0x060400\n")
+	s.addToIOMMUGroup(t, "0000:00:01.0", 3)
+	s.bindDriver(t, "0000:00:01.0", "pcieport")
+	s.addToIOMMUGroup(t, "0000:01:00.4", 3)
+
+	pf := s.newPF(t, pfPCIAddr)
+
+	vfs, err := pf.GetPassthroughableVirtualFunctions()
+	require.NoError(t, err)
+	require.Len(t, vfs, 2)
+	require.Equal(t, "0000:01:00.1", vfs[0].GetPCIAddress())
+	require.Equal(t, "0000:01:00.4", vfs[1].GetPCIAddress())
+
+	s.unbindDriver(t, "0000:01:00.3")
+	s.bindDriver(t, "0000:01:00.3", "vfio-pci")
+
+	// 0000:01:00.2 can be rebound to vfio now, but 0000:01:00.3 still shares the group with the kernel bound VF
+	vfs, err = pf.GetPassthroughableVirtualFunctions()
+	require.NoError(t, err)
+	require.Len(t, vfs, 3)
+	require.Equal(t, "0000:01:00.2", vfs[1].GetPCIAddress())
+}
+
+func TestPhysicalFunction_GetVirtualFunctionsDetailed(t *testing.T) {
+	s := newSysfs(t)
+
+	s.createPF(t, pfPCIAddr, 3)
+	s.createVF(t, pfPCIAddr, 0, "0000:01:00.1")
+	s.createVF(t, pfPCIAddr, 1, "0000:01:00.2")
+	s.createVF(t, pfPCIAddr, 2, "0000:01:00.3")
+
+	s.bindDriver(t, "0000:01:00.1", "ixgbevf")
+	s.bindDriver(t, "0000:01:00.2", "vfio-pci")
+
+	pf := s.newPF(t, pfPCIAddr)
+
+	details, err := pf.GetVirtualFunctionsDetailed()
+	require.NoError(t, err)
+	require.Equal(t, []*pcifunction.VirtualFunctionDetail{
+		{Index: 0, PCIAddress: "0000:01:00.1", Driver: "ixgbevf", Free: false},
+		{Index: 1, PCIAddress: "0000:01:00.2", Driver: "vfio-pci", Free: true},
+		{Index: 2, PCIAddress: "0000:01:00.3", Driver: "", Free: true},
+	}, details)
+}
+
+func TestDetectVFCountDrift(t *testing.T) {
+	s := newSysfs(t)
+
+	s.createPF(t, pfPCIAddr, 3)
+	s.createPF(t, "0000:02:00.0", 4)
+	s.createPF(t, "0000:03:00.0", 2)
+
+	drift, err := pcifunction.DetectVFCountDrift(s.devicesPath, map[string]int{
+		pfPCIAddr:      5,
+		"0000:02:00.0": 2,
+		"0000:03:00.0": 2,
+	})
+	require.NoError(t, err)
+	require.Equal(t, map[string]int{
+		pfPCIAddr:      2,
+		"0000:02:00.0": -2,
+		"0000:03:00.0": 0,
+	}, drift)
+
+	drift, err = pcifunction.DetectVFCountDrift(s.devicesPath, map[string]int{
+		pfPCIAddr:      3,
+		"0000:04:00.0": 1,
+	})
+	require.True(t, errors.Is(err, pcifunction.ErrDeviceNotFound))
+	require.Contains(t, err.Error(), "0000:04:00.0")
+	require.Equal(t, map[string]int{pfPCIAddr: 0}, drift)
+}
+
+func TestGetFreeVirtualFunctionsByPF(t *testing.T) {
+	s := newSysfs(t)
+
+	s.createPF(t, pfPCIAddr, 3)
+	s.createVF(t, pfPCIAddr, 0, "0000:01:00.1")
+	s.createVF(t, pfPCIAddr, 1, "0000:01:00.2")
+	s.createVF(t, pfPCIAddr, 2, "0000:01:00.3")
+	s.bindDriver(t, "0000:01:00.1", "ixgbevf")
+	s.bindDriver(t, "0000:01:00.2", "vfio-pci")
+
+	const emptyPFPCIAddr = "0000:02:00.0"
+	s.createPF(t, emptyPFPCIAddr, 1)
+
+	freeVFs, err := pcifunction.GetFreeVirtualFunctionsByPF([]*pcifunction.PhysicalFunction{
+		s.newPF(t, pfPCIAddr),
+		s.newPF(t, emptyPFPCIAddr),
+	})
+	require.NoError(t, err)
+	require.Equal(t, map[string]int{pfPCIAddr: 2}, freeVFs)
+}
+
+func TestPhysicalFunction_GetVirtualFunctionsByNUMANode(t *testing.T) {
+	s := newSysfs(t)
+
+	s.createPF(t, pfPCIAddr, 3)
+	s.createVF(t, pfPCIAddr, 0, "0000:01:00.1")
+	s.createVF(t, pfPCIAddr, 1, "0000:01:00.2")
+	s.createVF(t, pfPCIAddr, 2, "0000:01:00.3")
+
+	s.writeFile(t, "0000:01:00.1", "numa_node", "1\n")
+	s.writeFile(t, "0000:01:00.2", "numa_node", "-1\n")
+	s.writeFile(t, "0000:01:00.3", "numa_node", "1\n")
+
+	pf := s.newPF(t, pfPCIAddr)
+
+	vfs, err := pf.GetVirtualFunctionsByNUMANode()
+	require.NoError(t, err)
+	require.Len(t, vfs, 2)
+	require.Len(t, vfs[1], 2)
+	require.Len(t, vfs[pcifunction.NoNUMANode], 1)
+	require.Equal(t, "0000:01:00.2", vfs[pcifunction.NoNUMANode][0].GetPCIAddress())
+
+	require.NoError(t, os.RemoveAll(filepath.Join(s.devicesPath, "0000:01:00.3")))
+
+	vfs, err = pf.GetVirtualFunctionsByNUMANode()
+	require.NoError(t, err)
+	require.Len(t, vfs[1], 1)
+	require.Equal(t, "0000:01:00.1", vfs[1][0].GetPCIAddress())
+}
+
+func TestPhysicalFunction_GetVirtualFunctionsOrdered(t *testing.T) {
+	s := newSysfs(t)
+
+	const vfsCount = 12
+
+	s.createPF(t, pfPCIAddr, vfsCount)
+	for i := 0; i < vfsCount; i++ {
+		s.createVF(t, pfPCIAddr, i, fmt.Sprintf("0000:02:%02x.0", vfsCount-i))
+	}
+
+	pf := s.newPF(t, pfPCIAddr)
+
+	vfs, err := pf.GetVirtualFunctionsOrdered(pcifunction.ByIndex)
+	require.NoError(t, err)
+	require.Len(t, vfs, vfsCount)
+	for i, vf := range vfs {
+		require.Equal(t, fmt.Sprintf("0000:02:%02x.0", vfsCount-i), vf.GetPCIAddress())
+	}
+	require.Equal(t, vfs, pf.GetVirtualFunctions())
+
+	vfs, err = pf.GetVirtualFunctionsOrdered(pcifunction.ByPCIAddress)
+	require.NoError(t, err)
+	require.Len(t, vfs, vfsCount)
+	for i, vf := range vfs {
+		require.Equal(t, fmt.Sprintf("0000:02:%02x.0", i+1), vf.GetPCIAddress())
+	}
+}
+
+func TestPhysicalFunction_GetVirtualFunctionPCIAddress(t *testing.T) {
+	s := newSysfs(t)
+
+	const vfsCount = 10
+
+	s.createPF(t, pfPCIAddr, vfsCount)
+	s.writeFile(t, pfPCIAddr, "sriov_offset", "256\n")
+	s.writeFile(t, pfPCIAddr, "sriov_stride", "2\n")
+	for i := 0; i < vfsCount; i++ {
+		s.createVF(t, pfPCIAddr, i, fmt.Sprintf("0000:02:%02x.%x", i/4, i%4*2))
+	}
+
+	pf := s.newPF(t, pfPCIAddr)
+
+	for i, vf := range pf.GetVirtualFunctions() {
+		vfPCIAddr, err := pf.GetVirtualFunctionPCIAddress(i)
+		require.NoError(t, err)
+		require.Equal(t, vf.GetPCIAddress(), vfPCIAddr)
+	}
+}
+
+func TestPhysicalFunction_ValidateVirtualFunctionsLayout(t *testing.T) {
+	s := newSysfs(t)
+
+	s.createPF(t, pfPCIAddr, 2)
+	s.writeFile(t, pfPCIAddr, "sriov_offset", "1\n")
+	s.writeFile(t, pfPCIAddr, "sriov_stride", "1\n")
+	s.createVF(t, pfPCIAddr, 0, "0000:01:00.1")
+	pfPath := filepath.Join(s.devicesPath, pfPCIAddr)
+	require.NoError(t, os.Symlink(filepath.Join("..", "01:00.2"), filepath.Join(pfPath, "virtfn1")))
+
+	pf := s.newPF(t, pfPCIAddr)
+	require.NoError(t, pf.ValidateVirtualFunctionsLayout())
+
+	s.createVF(t, pfPCIAddr, 2, "0000:01:00.4")
+	require.True(t, errors.Is(pf.ValidateVirtualFunctionsLayout(), pcifunction.ErrInvalidVFLayout))
+
+	require.NoError(t, os.Remove(filepath.Join(pfPath, "virtfn2")))
+	require.NoError(t, os.Symlink(filepath.Join("..", "invalid"), filepath.Join(pfPath, "virtfn2")))
+	require.True(t, errors.Is(pf.ValidateVirtualFunctionsLayout(), pcifunction.ErrInvalidVFLayout))
+}
+
+func TestPhysicalFunction_DistributeMSIXEvenly(t *testing.T) {
+	s := newSysfs(t)
+
+	s.createPF(t, pfPCIAddr, 3)
+	for i := 0; i < 3; i++ {
+		vfPCIAddr := fmt.Sprintf("0000:01:00.%d", i+1)
+		s.createVF(t, pfPCIAddr, i, vfPCIAddr)
+		s.writeFile(t, vfPCIAddr, "sriov_vf_msix_count", "0\n")
+	}
+
+	pf := s.newPF(t, pfPCIAddr)
+
+	_, err := pf.GetVFTotalMSIX()
+	require.True(t, errors.Is(err, pcifunction.ErrUnsupported))
+	require.True(t, errors.Is(pf.DistributeMSIXEvenly(), pcifunction.ErrUnsupported))
+
+	s.writeFile(t, pfPCIAddr, "sriov_vf_total_msix", "2\n")
+	require.True(t, errors.Is(pf.DistributeMSIXEvenly(), pcifunction.ErrMSIXBudgetExceeded))
+
+	s.writeFile(t, pfPCIAddr, "sriov_vf_total_msix", "16\n")
+
+	totalMSIX, err := pf.GetVFTotalMSIX()
+	require.NoError(t, err)
+	require.Equal(t, 16, totalMSIX)
+
+	require.NoError(t, pf.DistributeMSIXEvenly())
+	for _, vf := range pf.GetVirtualFunctions() {
+		msixCount, err := vf.GetMSIXCount()
+		require.NoError(t, err)
+		require.Equal(t, 5, msixCount)
+	}
+}
+
+func TestPhysicalFunction_CanConfigure(t *testing.T) {
+	s := newSysfs(t)
+
+	s.createPF(t, pfPCIAddr, 1)
+	s.bindDriver(t, pfPCIAddr, "ixgbe")
+	require.NoError(t, ioutil.WriteFile(filepath.Join(s.driversPath, "ixgbe", "unbind"), nil, filePerm))
+
+	pf := s.newPF(t, pfPCIAddr)
+	require.NoError(t, pf.CanConfigure())
+
+	require.NoError(t, os.Remove(filepath.Join(s.driversPath, "ixgbe", "unbind")))
+	require.Error(t, pf.CanConfigure())
+}
+
+func TestPhysicalFunction_GetSriovVFs(t *testing.T) {
+	s := newSysfs(t)
+	s.createPF(t, pfPCIAddr, 3)
+	pf := s.newPF(t, pfPCIAddr)
+
+	numVFs, err := pf.GetSriovNumVFs()
+	require.NoError(t, err)
+	require.Equal(t, 3, numVFs)
+
+	totalVFs, err := pf.GetSriovTotalVFs()
+	require.NoError(t, err)
+	require.Equal(t, 8, totalVFs)
+}
+
+func TestGetBoundDrivers(t *testing.T) {
+	s := newSysfs(t)
+
+	s.createPF(t, pfPCIAddr, 3)
+	s.createVF(t, pfPCIAddr, 0, "0000:01:00.1")
+	s.createVF(t, pfPCIAddr, 1, "0000:01:00.2")
+	s.createVF(t, pfPCIAddr, 2, "0000:01:00.3")
+
+	s.bindDriver(t, "0000:01:00.1", "ixgbevf")
+	s.bindDriver(t, "0000:01:00.2", "vfio-pci")
+	require.NoError(t, ioutil.WriteFile(filepath.Join(s.devicesPath, "0000:01:00.3", "driver"), nil, filePerm))
+
+	pf := s.newPF(t, pfPCIAddr)
+
+	drivers, err := pcifunction.GetBoundDrivers(append(pf.GetVirtualFunctions(), &pf.Function))
+
+	var multiErr pcifunction.MultiError
+	require.True(t, errors.As(err, &multiErr))
+	require.Len(t, multiErr, 1)
+	require.Error(t, multiErr["0000:01:00.3"])
+
+	require.Equal(t, map[string]string{
+		pfPCIAddr:      "",
+		"0000:01:00.1": "ixgbevf",
+		"0000:01:00.2": "vfio-pci",
+	}, drivers)
+}
+
+func TestPhysicalFunction_EnsureVirtualFunctions(t *testing.T) {
+	samples := []struct {
+		name       string
+		configured int
+		vfsCount   int
+	}{
+		{
+			name:       "0 -> 0",
+			configured: 0,
+			vfsCount:   0,
+		},
+		{
+			name:       "0 -> 2",
+			configured: 0,
+			vfsCount:   2,
+		},
+		{
+			name:       "2 -> 2",
+			configured: 2,
+			vfsCount:   2,
+		},
+		{
+			name:       "2 -> 4",
+			configured: 2,
+			vfsCount:   4,
+		},
+		{
+			name:       "2 -> 0",
+			configured: 2,
+			vfsCount:   0,
+		},
+		{
+			name:       "0 -> 1",
+			configured: 0,
+			vfsCount:   1,
+		},
+		{
+			name:       "0 -> capacity",
+			configured: 0,
+			vfsCount:   8,
+		},
+	}
+
+	for i := range samples {
+		sample := samples[i]
+		t.Run(sample.name, func(t *testing.T) {
+			s := newSysfs(t)
+			s.createPF(t, pfPCIAddr, 1)
+			pf := s.newPF(t, pfPCIAddr)
+
+			s.writeFile(t, pfPCIAddr, "sriov_numvfs", strconv.Itoa(sample.configured))
+
+			require.NoError(t, pf.EnsureVirtualFunctions(context.Background(), sample.vfsCount))
+
+			data, err := ioutil.ReadFile(filepath.Join(s.devicesPath, pfPCIAddr, "sriov_numvfs"))
+			require.NoError(t, err)
+			require.Equal(t, strconv.Itoa(sample.vfsCount), string(data))
+		})
+	}
+}
+
+func TestPhysicalFunction_EnsureVirtualFunctions_WaitForZeroVFs(t *testing.T) {
+	s := newSysfs(t)
+	s.createPF(t, pfPCIAddr, 2)
+	s.createVF(t, pfPCIAddr, 0, "0000:01:00.1")
+	s.createVF(t, pfPCIAddr, 1, "0000:01:00.2")
+	pf := s.newPF(t, pfPCIAddr)
+	require.Len(t, pf.GetVirtualFunctions(), 2)
+
+	// VF 1 is already removed, VF 0 is never removed, so the new VFs number is not written
+	require.NoError(t, os.Remove(filepath.Join(s.devicesPath, pfPCIAddr, "virtfn1")))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	require.True(t, errors.Is(pf.EnsureVirtualFunctions(ctx, 3), context.DeadlineExceeded))
+
+	data, err := ioutil.ReadFile(filepath.Join(s.devicesPath, pfPCIAddr, "sriov_numvfs"))
+	require.NoError(t, err)
+	require.Equal(t, "0", string(data))
+
+	vfs := pf.GetVirtualFunctions()
+	require.Len(t, vfs, 1)
+	require.Equal(t, "0000:01:00.1", vfs[0].GetPCIAddress())
+
+	s.writeFile(t, pfPCIAddr, "sriov_numvfs", "1")
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		_ = os.Remove(filepath.Join(s.devicesPath, pfPCIAddr, "virtfn0"))
+	}()
+
+	ctx, cancel = context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	require.NoError(t, pf.EnsureVirtualFunctions(ctx, 2))
+
+	data, err = ioutil.ReadFile(filepath.Join(s.devicesPath, pfPCIAddr, "sriov_numvfs"))
+	require.NoError(t, err)
+	require.Equal(t, "2", string(data))
+}
+
+func TestPhysicalFunction_EnsureVirtualFunctions_Invalid(t *testing.T) {
+	s := newSysfs(t)
+	s.createPF(t, pfPCIAddr, 2)
+	pf := s.newPF(t, pfPCIAddr)
+
+	for _, vfsCount := range []int{-1, 9} {
+		require.True(t, errors.Is(pf.EnsureVirtualFunctions(context.Background(), vfsCount), pcifunction.ErrInvalidVFsCount))
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(s.devicesPath, pfPCIAddr, "sriov_numvfs"))
+	require.NoError(t, err)
+	require.Equal(t, "2", strings.TrimSpace(string(data)))
+}
+
+func TestPhysicalFunction_WaitForZeroVFs(t *testing.T) {
+	s := newSysfs(t)
+	s.createPF(t, pfPCIAddr, 1)
+	s.createVF(t, pfPCIAddr, 0, "0000:01:00.1")
+	pf := s.newPF(t, pfPCIAddr)
+
+	s.writeFile(t, pfPCIAddr, "sriov_numvfs", "0")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	require.True(t, errors.Is(pf.WaitForZeroVFs(ctx), context.DeadlineExceeded))
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		_ = os.Remove(filepath.Join(s.devicesPath, pfPCIAddr, "virtfn0"))
+	}()
+
+	ctx, cancel = context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	require.NoError(t, pf.WaitForZeroVFs(ctx))
+}
@@ -0,0 +1,51 @@
+// Copyright (c) 2021 Doc.ai and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pcifunction
+
+import "github.com/pkg/errors"
+
+const (
+	runtimePMStatusFile  = "power/runtime_status"
+	runtimePMControlFile = "power/control"
+	runtimePMAuto        = "auto"
+	runtimePMOn          = "on"
+)
+
+// GetRuntimePMStatus returns f runtime power management status: "active", "suspended", "suspending", "resuming",
+// "error" or "unsupported". Returns ErrUnsupported if the kernel doesn't expose runtime PM for f.
+func (f *Function) GetRuntimePMStatus() (string, error) {
+	status, err := f.readAttribute(runtimePMStatusFile)
+	if errors.Is(err, ErrAttributeNotFound) {
+		return "", errors.Wrapf(ErrUnsupported, "runtime PM is not supported for the device: %v", f.address)
+	}
+	return status, err
+}
+
+// SetRuntimePMControl allows the kernel to runtime suspend f if auto is true, else keeps f always active. Returns
+// ErrUnsupported if the kernel doesn't expose runtime PM for f.
+func (f *Function) SetRuntimePMControl(auto bool) error {
+	control := runtimePMOn
+	if auto {
+		control = runtimePMAuto
+	}
+
+	err := f.writeAttribute(runtimePMControlFile, control)
+	if errors.Is(err, ErrAttributeNotFound) {
+		return errors.Wrapf(ErrUnsupported, "runtime PM is not supported for the device: %v", f.address)
+	}
+	return err
+}
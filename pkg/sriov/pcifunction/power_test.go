@@ -0,0 +1,51 @@
+// Copyright (c) 2021 Doc.ai and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//+build !windows
+
+package pcifunction_test
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov/pcifunction"
+)
+
+func TestFunction_RuntimePM(t *testing.T) {
+	s := newSysfs(t)
+	s.createPF(t, pfPCIAddr, 1)
+	pf := s.newPF(t, pfPCIAddr)
+
+	_, err := pf.GetRuntimePMStatus()
+	require.True(t, errors.Is(err, pcifunction.ErrUnsupported))
+	require.True(t, errors.Is(pf.SetRuntimePMControl(false), pcifunction.ErrUnsupported))
+
+	s.writeFile(t, pfPCIAddr, "power/runtime_status", "suspended\n")
+	s.writeFile(t, pfPCIAddr, "power/control", "auto\n")
+
+	status, err := pf.GetRuntimePMStatus()
+	require.NoError(t, err)
+	require.Equal(t, "suspended", status)
+
+	require.NoError(t, pf.SetRuntimePMControl(false))
+
+	control, err := pf.ReadAttribute("power/control")
+	require.NoError(t, err)
+	require.Equal(t, "on", control)
+}
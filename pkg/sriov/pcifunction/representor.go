@@ -0,0 +1,66 @@
+// Copyright (c) 2021 Doc.ai and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pcifunction
+
+import (
+	"regexp"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+const physPortNameFile = "phys_port_name"
+
+// VF representor phys_port_name is "pf<N>vf<M>" optionally prefixed with "c<N>" controller number, uplink
+// representor phys_port_name is "p<N>"
+var vfRepresentorPortName = regexp.MustCompile(`^(?:c\d+)?pf\d+vf(\d+)$`)
+
+// GetRepresentorMapping returns VF index -> VF representor net interface name mapping for the pf in switchdev mode.
+// Uplink representor is not included. Returns ErrUnsupported if the pf has no VF representors, e.g. if the pf is in
+// legacy mode.
+func (pf *PhysicalFunction) GetRepresentorMapping() (map[int]string, error) {
+	ifNames, err := pf.GetNetInterfacesNames()
+	if err != nil {
+		return nil, err
+	}
+
+	representors := map[int]string{}
+	for _, ifName := range ifNames {
+		// reading phys_port_name fails for the net interfaces not supporting it
+		portName, err := readFile(pf.withDevicePath(netInterfacesPath, ifName, physPortNameFile))
+		if err != nil {
+			continue
+		}
+
+		match := vfRepresentorPortName.FindStringSubmatch(portName)
+		if match == nil {
+			continue
+		}
+
+		vfIndex, err := strconv.Atoi(match[1])
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid VF representor port name for the device: %v %v", pf.address, portName)
+		}
+		representors[vfIndex] = ifName
+	}
+
+	if len(representors) == 0 {
+		return nil, errors.Wrapf(ErrUnsupported, "no VF representors found, switchdev mode is not enabled for the device: %v",
+			pf.address)
+	}
+	return representors, nil
+}
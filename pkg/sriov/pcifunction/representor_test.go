@@ -0,0 +1,50 @@
+// Copyright (c) 2021 Doc.ai and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//+build !windows
+
+package pcifunction_test
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov/pcifunction"
+)
+
+func TestPhysicalFunction_GetRepresentorMapping(t *testing.T) {
+	s := newSysfs(t)
+	s.createPF(t, pfPCIAddr, 2)
+	s.writeFile(t, pfPCIAddr, "net/enp1s0f0/phys_port_name", "p0\n")
+
+	pf := s.newPF(t, pfPCIAddr)
+
+	_, err := pf.GetRepresentorMapping()
+	require.True(t, errors.Is(err, pcifunction.ErrUnsupported))
+
+	s.writeFile(t, pfPCIAddr, "net/enp1s0f0_0/phys_port_name", "pf0vf0\n")
+	s.writeFile(t, pfPCIAddr, "net/enp1s0f0_1/phys_port_name", "c1pf0vf1\n")
+	s.writeFile(t, pfPCIAddr, "net/enp1s0f0_2/type", "1\n")
+
+	representors, err := pf.GetRepresentorMapping()
+	require.NoError(t, err)
+	require.Equal(t, map[int]string{
+		0: "enp1s0f0_0",
+		1: "enp1s0f0_1",
+	}, representors)
+}
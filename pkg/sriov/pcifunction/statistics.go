@@ -0,0 +1,69 @@
+// Copyright (c) 2021 Doc.ai and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pcifunction
+
+import (
+	"os"
+
+	"github.com/pkg/errors"
+)
+
+const netInterfaceStatistics = "statistics"
+
+// NetInterfaceStatistics is a set of net interface traffic counters
+type NetInterfaceStatistics struct {
+	RxBytes   uint64
+	TxBytes   uint64
+	RxPackets uint64
+	TxPackets uint64
+	RxErrors  uint64
+	TxErrors  uint64
+	RxDropped uint64
+	TxDropped uint64
+}
+
+// GetNetInterfaceStatistics returns traffic counters of the f net interface, returns ErrNoNetInterface if f has no
+// net interface
+func (f *Function) GetNetInterfaceStatistics() (*NetInterfaceStatistics, error) {
+	ifName, err := f.GetNetInterfaceName()
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return nil, errors.Wrapf(ErrNoNetInterface, "no interfaces found for the device: %v", f.address)
+	case err != nil:
+		return nil, err
+	}
+
+	stats := new(NetInterfaceStatistics)
+	for name, counter := range map[string]*uint64{
+		"rx_bytes":   &stats.RxBytes,
+		"tx_bytes":   &stats.TxBytes,
+		"rx_packets": &stats.RxPackets,
+		"tx_packets": &stats.TxPackets,
+		"rx_errors":  &stats.RxErrors,
+		"tx_errors":  &stats.TxErrors,
+		"rx_dropped": &stats.RxDropped,
+		"tx_dropped": &stats.TxDropped,
+	} {
+		value, err := readUintFromFile(f.withDevicePath(netInterfacesPath, ifName, netInterfaceStatistics, name))
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get %v statistics for the device: %v", name, f.address)
+		}
+		*counter = uint64(value)
+	}
+
+	return stats, nil
+}
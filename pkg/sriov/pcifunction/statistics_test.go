@@ -0,0 +1,57 @@
+// Copyright (c) 2021 Doc.ai and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//+build !windows
+
+package pcifunction_test
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov/pcifunction"
+)
+
+func TestFunction_GetNetInterfaceStatistics(t *testing.T) {
+	s := newSysfs(t)
+	s.createPF(t, pfPCIAddr, 1)
+	pf := s.newPF(t, pfPCIAddr)
+
+	_, err := pf.GetNetInterfaceStatistics()
+	require.True(t, errors.Is(err, pcifunction.ErrNoNetInterface))
+
+	for i, name := range []string{
+		"rx_bytes", "tx_bytes", "rx_packets", "tx_packets", "rx_errors", "tx_errors", "rx_dropped", "tx_dropped",
+	} {
+		s.writeFile(t, pfPCIAddr, "net/eth0/statistics/"+name, strconv.Itoa(i+1)+"\n")
+	}
+
+	stats, err := pf.GetNetInterfaceStatistics()
+	require.NoError(t, err)
+	require.Equal(t, &pcifunction.NetInterfaceStatistics{
+		RxBytes:   1,
+		TxBytes:   2,
+		RxPackets: 3,
+		TxPackets: 4,
+		RxErrors:  5,
+		TxErrors:  6,
+		RxDropped: 7,
+		TxDropped: 8,
+	}, stats)
+}
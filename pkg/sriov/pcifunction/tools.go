@@ -1,4 +1,4 @@
-// Copyright (c) 2020 Doc.ai and/or its affiliates.
+// Copyright (c) 2020-2021 Doc.ai and/or its affiliates.
 //
 // SPDX-License-Identifier: Apache-2.0
 //
@@ -17,6 +17,7 @@
 package pcifunction
 
 import (
+	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
@@ -31,20 +32,165 @@ func isFileExists(path string) bool {
 	return err == nil
 }
 
-func readUintFromFile(path string) (uint, error) {
+// readFile reads the whole file with a single open, not existing file error is translated to ErrAttributeNotFound
+func readFile(path string) (string, error) {
 	data, err := ioutil.ReadFile(filepath.Clean(path))
+	switch {
+	case os.IsNotExist(err):
+		return "", errors.Wrapf(ErrAttributeNotFound, "file doesn't exist: %v", path)
+	case err != nil:
+		return "", errors.Wrapf(err, "unable to read file: %v", path)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// writeFile writes the value to the existing file, sysfs attributes can't be created, so O_CREATE is not used here to
+// get ErrAttributeNotFound for the missing attribute instead of creating a regular file
+func writeFile(path, value string) error {
+	file, err := os.OpenFile(filepath.Clean(path), os.O_WRONLY|os.O_TRUNC, 0)
+	switch {
+	case os.IsNotExist(err):
+		return errors.Wrapf(ErrAttributeNotFound, "file doesn't exist: %v", path)
+	case err != nil:
+		return errors.Wrapf(err, "unable to open file: %v", path)
+	}
+	defer func() { _ = file.Close() }()
+
+	if _, err := file.WriteString(value); err != nil {
+		return errors.Wrapf(err, "unable to write file: %v", path)
+	}
+	return nil
+}
+
+func readUintFromFile(path string) (uint, error) {
+	data, err := readFile(path)
 	if err != nil {
-		return 0, errors.Wrapf(err, "unable to locate file: %v", path)
+		return 0, err
 	}
 
-	value, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	value, err := strconv.Atoi(data)
 	if err != nil {
-		return 0, errors.Wrapf(err, "unable to convert string to int: %v", string(data))
+		return 0, errors.Wrapf(err, "unable to convert string to int: %v", data)
 	}
 
 	return uint(value), nil
 }
 
+func readHexUintFromFile(path string) (uint64, error) {
+	data, err := readFile(path)
+	if err != nil {
+		return 0, err
+	}
+
+	value, err := strconv.ParseUint(data, 0, 64)
+	if err != nil {
+		return 0, errors.Wrapf(err, "unable to convert string to uint: %v", data)
+	}
+
+	return value, nil
+}
+
+func validateAttribute(attr string) error {
+	if attr == "" || filepath.IsAbs(attr) {
+		return errors.Wrapf(ErrInvalidAttribute, "%v", attr)
+	}
+	for _, elem := range strings.Split(filepath.ToSlash(attr), "/") {
+		if elem == ".." {
+			return errors.Wrapf(ErrInvalidAttribute, "%v", attr)
+		}
+	}
+	return nil
+}
+
+// parsePCIAddress parses PCI address into domain and routing ID (bus << 8 | device << 3 | function)
+func parsePCIAddress(pciAddr string) (domain, routingID uint, err error) {
+	if validShortPCIAddr.MatchString(pciAddr) {
+		pciAddr = bdfDomain + pciAddr
+	}
+	if !validLongPCIAddr.MatchString(pciAddr) {
+		return 0, 0, errors.Errorf("invalid PCI address format: %v", pciAddr)
+	}
+
+	var bus, device, function uint
+	if _, err := fmt.Sscanf(pciAddr, "%04x:%02x:%02x.%1x", &domain, &bus, &device, &function); err != nil {
+		return 0, 0, errors.Wrapf(err, "invalid PCI address format: %v", pciAddr)
+	}
+
+	return domain, bus<<8 | device<<3 | function, nil
+}
+
+// normalizePCIAddress converts short or upper case PCI address into the canonical long lower case form
+func normalizePCIAddress(pciAddr string) (string, error) {
+	domain, routingID, err := parsePCIAddress(strings.ToLower(pciAddr))
+	if err != nil {
+		return "", err
+	}
+	return formatPCIAddress(domain, routingID)
+}
+
+func formatPCIAddress(domain, routingID uint) (string, error) {
+	if routingID > 0xffff {
+		return "", errors.Errorf("routing ID is out of the PCI domain: %04x:%x", domain, routingID)
+	}
+	return fmt.Sprintf("%04x:%02x:%02x.%x", domain, routingID>>8, routingID>>3&0x1f, routingID&0x7), nil
+}
+
+// maxCPUIndex is the max CPU index supported by Linux (NR_CPUS is at most 8192), it limits CPU list parsing of corrupted
+// CPU lists
+const maxCPUIndex = 8191
+
+// parseCPUList parses Linux CPU list format (e.g. "0-3,8-11") into the list of CPU indices
+func parseCPUList(s string) ([]int, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, ErrEmptyCPUList
+	}
+
+	var cpus []int
+	for _, cpuRange := range strings.Split(s, ",") {
+		bounds := strings.SplitN(cpuRange, "-", 2)
+
+		first, err := strconv.Atoi(bounds[0])
+		if err != nil || first < 0 {
+			return nil, errors.Wrapf(ErrInvalidCPUList, "invalid CPU range: %s", cpuRange)
+		}
+
+		last := first
+		if len(bounds) == 2 {
+			if last, err = strconv.Atoi(bounds[1]); err != nil || last < first {
+				return nil, errors.Wrapf(ErrInvalidCPUList, "invalid CPU range: %s", cpuRange)
+			}
+		}
+		if last > maxCPUIndex {
+			return nil, errors.Wrapf(ErrInvalidCPUList, "CPU index is out of range: %s", cpuRange)
+		}
+
+		for cpu := first; cpu <= last; cpu++ {
+			cpus = append(cpus, cpu)
+		}
+	}
+
+	return cpus, nil
+}
+
+// checkSymlinkWithinRoot returns ErrPathEscape if path symlink resolves out of root
+func checkSymlinkWithinRoot(path, root string) error {
+	realPath, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return errors.Wrapf(err, "error evaluating symbolic link: %s", path)
+	}
+	realRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return errors.Wrapf(err, "error evaluating root: %s", root)
+	}
+
+	relPath, err := filepath.Rel(realRoot, realPath)
+	if err != nil || relPath == ".." || strings.HasPrefix(relPath, ".."+string(filepath.Separator)) {
+		return errors.Wrapf(ErrPathEscape, "%s resolves out of %s: %s", path, root, realPath)
+	}
+	return nil
+}
+
 func evalSymlinkAndGetBaseName(path string) (string, error) {
 	fileInfo, err := os.Lstat(path)
 	if err != nil {
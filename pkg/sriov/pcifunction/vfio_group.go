@@ -0,0 +1,56 @@
+// Copyright (c) 2021 Doc.ai and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//+build !windows
+
+package pcifunction
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/pkg/errors"
+	"golang.org/x/sys/unix"
+)
+
+// GetVFIOGroupDeviceNode returns path and device numbers of the f IOMMU group vfio device node in vfioDir (usually
+// /dev/vfio), these are the values needed for the cgroup devices.allow rule. Returns ErrUnsupported if f has no IOMMU
+// group or there is no vfio device node for it, e.g. if f is not bound to the vfio driver.
+func (f *Function) GetVFIOGroupDeviceNode(vfioDir string) (major, minor uint32, path string, err error) {
+	if !isFileExists(f.withDevicePath(iommuGroup)) {
+		return 0, 0, "", errors.Wrapf(ErrUnsupported, "IOMMU is not available for the device: %v", f.address)
+	}
+
+	iommuGroup, err := f.GetIOMMUGroup()
+	if err != nil {
+		return 0, 0, "", err
+	}
+
+	path = filepath.Join(vfioDir, strconv.FormatUint(uint64(iommuGroup), 10))
+
+	info := new(unix.Stat_t)
+	switch err := unix.Stat(path, info); {
+	case os.IsNotExist(err):
+		return 0, 0, "", errors.Wrapf(ErrUnsupported, "vfio device node doesn't exist for the device: %v %v",
+			f.address, path)
+	case err != nil:
+		return 0, 0, "", errors.Wrapf(err, "failed to get vfio device node info for the device: %v %v", f.address, path)
+	}
+
+	rdev := uint64(info.Rdev)
+	return unix.Major(rdev), unix.Minor(rdev), path, nil
+}
@@ -0,0 +1,56 @@
+// Copyright (c) 2021 Doc.ai and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//+build !windows
+
+package pcifunction_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/unix"
+
+	"github.com/networkservicemesh/sdk-sriov/pkg/sriov/pcifunction"
+)
+
+func TestFunction_GetVFIOGroupDeviceNode(t *testing.T) {
+	s := newSysfs(t)
+	s.createPF(t, pfPCIAddr, 1)
+	pf := s.newPF(t, pfPCIAddr)
+
+	vfioDir := filepath.Join(filepath.Dir(s.devicesPath), "vfio")
+	require.NoError(t, os.MkdirAll(vfioDir, mkdirPerm))
+
+	_, _, _, err := pf.GetVFIOGroupDeviceNode(vfioDir)
+	require.True(t, errors.Is(err, pcifunction.ErrUnsupported))
+
+	s.addToIOMMUGroup(t, pfPCIAddr, 5)
+
+	_, _, _, err = pf.GetVFIOGroupDeviceNode(vfioDir)
+	require.True(t, errors.Is(err, pcifunction.ErrUnsupported))
+
+	require.NoError(t, unix.Mknod(filepath.Join(vfioDir, "5"), unix.S_IFCHR|0666, int(unix.Mkdev(3, 4))))
+
+	major, minor, path, err := pf.GetVFIOGroupDeviceNode(vfioDir)
+	require.NoError(t, err)
+	require.Equal(t, uint32(3), major)
+	require.Equal(t, uint32(4), minor)
+	require.Equal(t, filepath.Join(vfioDir, "5"), path)
+}